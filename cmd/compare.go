@@ -0,0 +1,84 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/paniccaaa/stresstea/internal/compare"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareFormat     string
+	compareTolerances = compare.DefaultTolerances()
+)
+
+// compareCmd represents the compare command
+var compareCmd = &cobra.Command{
+	Use:   "compare <baseline> <candidate>",
+	Short: "Compare a candidate run against a baseline",
+	Long: `Compares two results files recorded with 'stresstea run --output': throughput,
+error rate and latency percentiles for the whole run and per scenario step.
+Latency changes come with bootstrap confidence intervals and a Mann-Whitney
+U test, so noise can be told apart from real changes.
+Exits with code 99 when the candidate is worse than the tolerances allow.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		write, err := compareWriter(compareFormat)
+		if err != nil {
+			return err
+		}
+		if err := compareTolerances.Validate(); err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		baseline, err := compare.Load(args[0])
+		if err != nil {
+			return err
+		}
+		candidate, err := compare.Load(args[1])
+		if err != nil {
+			return err
+		}
+
+		result := compare.Compare(baseline, candidate, compareTolerances)
+		if err := write(os.Stdout, result); err != nil {
+			return fmt.Errorf("failed to write comparison: %w", err)
+		}
+
+		if result.Regressions() > 0 {
+			return compare.ErrRegression
+		}
+		return nil
+	},
+}
+
+// compareWriter returns the renderer for the comparison format
+func compareWriter(format string) (func(io.Writer, compare.Result) error, error) {
+	switch format {
+	case "text":
+		return compare.WriteText, nil
+	case "json":
+		return compare.WriteJSON, nil
+	default:
+		return nil, fmt.Errorf("unsupported comparison format: %s (text or json)", format)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringVarP(&compareFormat, "format", "f", "text", "Output format (text or json)")
+	compareCmd.Flags().Float64Var(&compareTolerances.RPS, "rps-tolerance", compareTolerances.RPS, "Allowed throughput drop, percent")
+	compareCmd.Flags().Float64Var(&compareTolerances.ErrorRate, "error-tolerance", compareTolerances.ErrorRate, "Allowed error rate increase, percentage points")
+	compareCmd.Flags().Float64Var(&compareTolerances.Latency, "latency-tolerance", compareTolerances.Latency, "Allowed latency percentile increase, percent")
+	compareCmd.Flags().Float64Var(&compareTolerances.Confidence, "confidence", compareTolerances.Confidence, "Confidence level of the intervals and significance tests")
+	compareCmd.Flags().BoolVar(&compareTolerances.Significant, "significant-only", false, "Latency increases only regress when statistically significant")
+	compareCmd.Flags().DurationVar(&compareTolerances.MinLatency, "latency-floor", compareTolerances.MinLatency, "Latency increases smaller than this are never regressions")
+}
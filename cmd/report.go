@@ -0,0 +1,97 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/paniccaaa/stresstea/internal/ci"
+	"github.com/paniccaaa/stresstea/internal/report"
+	"github.com/paniccaaa/stresstea/internal/threshold"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportOutput string
+	reportFormat string
+)
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report <results-file>",
+	Short: "Generate report from test results",
+	Long: `Builds a report from the raw results recorded with 'stresstea run --output'.
+Supports text, JSON, HTML, Markdown and JUnit XML formats; the last two
+evaluate the thresholds recorded with the run.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		write, err := reportWriter(reportFormat)
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		summary, err := report.Replay(args[0])
+		if err != nil {
+			return err
+		}
+
+		if reportOutput == "" || reportOutput == "-" {
+			return write(os.Stdout, summary)
+		}
+
+		file, err := os.Create(reportOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %w", err)
+		}
+		defer file.Close()
+
+		if err := write(file, summary); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+
+		return file.Close()
+	},
+}
+
+// reportWriter returns the renderer for the report format
+func reportWriter(format string) (func(io.Writer, report.Summary) error, error) {
+	switch format {
+	case "text":
+		return report.WriteText, nil
+	case "json":
+		return report.WriteJSON, nil
+	case "html":
+		return report.WriteHTML, nil
+	case "markdown":
+		return withThresholds(ci.WriteMarkdown), nil
+	case "junit":
+		return withThresholds(ci.WriteJUnit), nil
+	default:
+		return nil, fmt.Errorf("unsupported report format: %s (text, json, html, markdown or junit)", format)
+	}
+}
+
+// withThresholds evaluates the thresholds recorded with the run for
+// renderers that report them
+func withThresholds(write func(io.Writer, report.Summary, []threshold.Result) error) func(io.Writer, report.Summary) error {
+	return func(w io.Writer, s report.Summary) error {
+		thresholds, err := threshold.ParseAll(s.Config)
+		if err != nil {
+			return err
+		}
+
+		return write(w, s, threshold.Evaluate(thresholds, s))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "Output file for the report (default stdout)")
+	reportCmd.Flags().StringVarP(&reportFormat, "format", "f", "text", "Report format (text, json, html, markdown or junit)")
+}
@@ -4,11 +4,18 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
 	"os"
 
+	"github.com/paniccaaa/stresstea/internal/compare"
+	"github.com/paniccaaa/stresstea/internal/threshold"
 	"github.com/spf13/cobra"
 )
 
+// exitChecksFailed is the exit code of a run that broke its thresholds and of
+// a comparison that found a regression
+const exitChecksFailed = 99
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "stresstea",
@@ -29,6 +36,9 @@ Features:
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	err := rootCmd.Execute()
+	if errors.Is(err, threshold.ErrFailed) || errors.Is(err, compare.ErrRegression) {
+		os.Exit(exitChecksFailed)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
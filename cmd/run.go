@@ -5,8 +5,11 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/mattn/go-isatty"
 	"github.com/paniccaaa/stresstea/internal/config"
 	"github.com/paniccaaa/stresstea/internal/engine"
 	"github.com/paniccaaa/stresstea/internal/parser"
@@ -14,13 +17,33 @@ import (
 )
 
 var (
-	target     string
-	duration   time.Duration
-	rate       int
-	concurrent int
-	configFile string
-	protocol   string
-	cpus       int
+	target      string
+	duration    time.Duration
+	rate        int
+	concurrent  int
+	configFile  string
+	protocol    string
+	cpus        int
+	arrival     string
+	seed        uint64
+	burstOn     time.Duration
+	burstOff    time.Duration
+	executor    string
+	thinkMin    time.Duration
+	thinkMax    time.Duration
+	noTUI       bool
+	thresholds  []string
+	output      string
+	htmlReport  string
+	junitReport string
+	mdSummary   string
+	interval    time.Duration
+	metricsAddr string
+	otlpURL     string
+	otlpHeaders []string
+	traceSample float64
+	outputs     []string
+	runID       string
 )
 
 // runCmd represents the run command
@@ -48,6 +71,19 @@ Supports HTTP and gRPC protocols.`,
 				return fmt.Errorf("failed to load configuration: %w", err)
 			}
 		} else {
+			arrivalCfg := &parser.ArrivalConfig{Type: arrival, Seed: seed, On: burstOn, Off: burstOff}
+			if err := parser.ValidateArrival(arrivalCfg); err != nil {
+				return err
+			}
+
+			thinkTime := &parser.ThinkTimeConfig{Min: thinkMin, Max: thinkMax}
+			if thinkTime.Max < thinkTime.Min {
+				thinkTime.Max = thinkTime.Min
+			}
+			if err := parser.ValidateExecutor(executor, thinkTime); err != nil {
+				return err
+			}
+
 			cfg = &parser.Config{
 				App: config.DefaultAppConfig(),
 				Test: &parser.TestRunConfig{
@@ -57,10 +93,82 @@ Supports HTTP and gRPC protocols.`,
 					Concurrent: concurrent,
 					Protocol:   protocol,
 					CPUs:       cpus,
+					Arrival:    arrivalCfg,
+					Executor:   executor,
+					ThinkTime:  thinkTime,
 				},
 			}
 		}
 
+		if output != "" {
+			cfg.App.Output = output
+		}
+		if htmlReport != "" {
+			cfg.App.Report = htmlReport
+		}
+		if junitReport != "" {
+			cfg.App.JUnit = junitReport
+		}
+		if mdSummary != "" {
+			cfg.App.Markdown = mdSummary
+		}
+		if interval > 0 {
+			cfg.App.Interval = interval
+		}
+		if metricsAddr != "" {
+			cfg.App.MetricsAddr = metricsAddr
+		}
+		if otlpURL != "" {
+			if cfg.App.OTLP == nil {
+				cfg.App.OTLP = config.DefaultOTLPConfig()
+			}
+			cfg.App.OTLP.Endpoint = otlpURL
+		}
+		for _, header := range otlpHeaders {
+			if cfg.App.OTLP == nil || cfg.App.OTLP.Endpoint == "" {
+				return fmt.Errorf("--otlp-header requires an OTLP endpoint")
+			}
+			name, value, ok := strings.Cut(header, "=")
+			if !ok || name == "" {
+				return fmt.Errorf("invalid --otlp-header %q, expected name=value", header)
+			}
+			if cfg.App.OTLP.Headers == nil {
+				cfg.App.OTLP.Headers = make(map[string]string)
+			}
+			cfg.App.OTLP.Headers[name] = value
+		}
+		for _, spec := range outputs {
+			stream, err := config.ParseStream(spec)
+			if err != nil {
+				return err
+			}
+			cfg.App.Streams = append(cfg.App.Streams, stream)
+		}
+		if runID != "" {
+			cfg.App.RunID = runID
+		}
+		if cmd.Flags().Changed("trace-sample") {
+			if cfg.App.OTLP == nil || cfg.App.OTLP.Endpoint == "" {
+				return fmt.Errorf("--trace-sample requires an OTLP endpoint")
+			}
+			if traceSample < 0 || traceSample > 1 {
+				return fmt.Errorf("--trace-sample must be between 0 and 1, got %v", traceSample)
+			}
+			cfg.App.OTLP.SampleRate = traceSample
+		}
+
+		for _, check := range thresholds {
+			cfg.Test.Thresholds = append(cfg.Test.Thresholds, parser.ThresholdConfig{Check: check})
+		}
+
+		// Без терминала (CI, пайпы) TUI не запускается
+		if noTUI || !isatty.IsTerminal(os.Stdout.Fd()) {
+			cfg.App.TUI.Headless = true
+		}
+
+		// Ошибки дальше относятся к самому прогону, а не к аргументам
+		cmd.SilenceUsage = true
+
 		return engine.Run(cfg)
 	},
 }
@@ -72,8 +180,28 @@ func init() {
 	runCmd.Flags().StringVarP(&target, "target", "t", "", "Target URL or gRPC endpoint")
 	runCmd.Flags().DurationVarP(&duration, "duration", "d", 30*time.Second, "Test duration")
 	runCmd.Flags().IntVarP(&rate, "rate", "r", 100, "Requests per second")
-	runCmd.Flags().IntVarP(&concurrent, "concurrent", "c", 10, "Number of concurrent connections")
+	runCmd.Flags().IntVarP(&concurrent, "concurrent", "c", 10, "Maximum number of in-flight requests")
 	runCmd.Flags().StringVarP(&configFile, "config", "f", "", "Path to YAML configuration file")
 	runCmd.Flags().StringVarP(&protocol, "protocol", "p", "http", "Protocol (http or grpc)")
 	runCmd.Flags().IntVarP(&cpus, "cpus", "", 0, "Number of CPUs to use (0 = all available)")
+	runCmd.Flags().StringVar(&arrival, "arrival", "constant", "Arrival distribution (constant, poisson or bursty)")
+	runCmd.Flags().Uint64Var(&seed, "seed", 0, "Random seed for the arrival distribution (0 = random, printed in the summary)")
+	runCmd.Flags().DurationVar(&burstOn, "burst-on", 0, "Length of a burst (bursty arrival)")
+	runCmd.Flags().DurationVar(&burstOff, "burst-off", 0, "Pause between bursts (bursty arrival)")
+	runCmd.Flags().StringVar(&executor, "executor", "arrival-rate", "Load model: arrival-rate (fixed RPS) or vus (concurrent users)")
+	runCmd.Flags().DurationVar(&thinkMin, "think-min", 0, "Minimum think time between iterations (vus executor)")
+	runCmd.Flags().DurationVar(&thinkMax, "think-max", 0, "Maximum think time between iterations (vus executor)")
+	runCmd.Flags().StringVarP(&output, "output", "o", "", "Record raw results to this file for 'stresstea report'")
+	runCmd.Flags().StringVar(&htmlReport, "report", "", "Write an HTML report to this file at the end of the run")
+	runCmd.Flags().StringVar(&junitReport, "junit", "", "Write a JUnit XML report with a test case per threshold to this file")
+	runCmd.Flags().StringVar(&mdSummary, "markdown", "", "Write a Markdown summary for pull request comments to this file")
+	runCmd.Flags().DurationVar(&interval, "interval", time.Second, "Width of the time-series buckets behind RPS and latency charts")
+	runCmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address during the run, e.g. :9090")
+	runCmd.Flags().StringVar(&otlpURL, "otlp-endpoint", "", "Push metrics and sampled request spans to this OTLP/HTTP receiver, e.g. http://localhost:4318")
+	runCmd.Flags().StringArrayVar(&otlpHeaders, "otlp-header", nil, "Header sent with OTLP exports as name=value, e.g. for authentication (repeatable)")
+	runCmd.Flags().Float64Var(&traceSample, "trace-sample", 0, "Share of HTTP requests traced with a traceparent header and a client span (0-1)")
+	runCmd.Flags().StringArrayVar(&outputs, "out", nil, "Stream metrics to influxdb=URL or statsd=HOST:PORT every --interval (repeatable)")
+	runCmd.Flags().StringVar(&runID, "run-id", "", "Tag for this run's metrics in external systems (default: start time)")
+	runCmd.Flags().StringArrayVar(&thresholds, "threshold", nil, "Pass/fail check such as 'p95 < 300ms' (repeatable)")
+	runCmd.Flags().BoolVar(&noTUI, "no-tui", false, "Disable the TUI: print progress to stderr and a summary to stdout")
 }
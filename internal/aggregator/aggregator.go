@@ -0,0 +1,378 @@
+// Package aggregator accumulates the results of a run. The engine feeds it
+// every result as it arrives; the TUI, reports, thresholds and exporters read
+// consistent snapshots of it, so what they show does not depend on whether
+// anyone is watching.
+package aggregator
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/hdr"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"github.com/paniccaaa/stresstea/internal/parser"
+	"github.com/paniccaaa/stresstea/internal/timeseries"
+)
+
+// latencyDigits is the precision of full-run latency histograms (0.1%)
+const latencyDigits = 3
+
+// labeledDigits is the precision of per-label latency histograms (1%)
+const labeledDigits = 2
+
+// maxDistinctErrors caps the error breakdown; further messages are grouped
+const maxDistinctErrors = 50
+
+// otherErrors collects messages beyond maxDistinctErrors
+const otherErrors = "other errors"
+
+// maxRecentErrors is how many of the latest error messages are kept
+const maxRecentErrors = 10
+
+// LatencyStats describes a latency distribution
+type LatencyStats struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// ErrorCount is one line of the error breakdown
+type ErrorCount struct {
+	Message string `json:"message"`
+	Count   int64  `json:"count"`
+}
+
+// HTTPPhases describes where the time of HTTP requests went. DNS, Connect
+// and TLS only cover the requests that went through the phase, i.e. opened a
+// new connection.
+type HTTPPhases struct {
+	Requests int64 `json:"requests"` // traced requests
+	Reused   int64 `json:"reused"`   // of which over a kept-alive connection
+
+	DNS      LatencyStats `json:"dns"`
+	Connect  LatencyStats `json:"connect"`
+	TLS      LatencyStats `json:"tls"`
+	TTFB     LatencyStats `json:"ttfb"`
+	Transfer LatencyStats `json:"transfer"`
+}
+
+// ReuseRate returns the share of requests that reused a connection, percent
+func (p HTTPPhases) ReuseRate() float64 {
+	if p.Requests == 0 {
+		return 0
+	}
+	return float64(p.Reused) / float64(p.Requests) * 100
+}
+
+// Snapshot is a consistent view of everything aggregated so far
+type Snapshot struct {
+	Start   time.Time
+	End     time.Time // completion of the last result, Start if there is none
+	Aborted string    // why the run was stopped early
+
+	Requests int64
+	Failed   int64
+	Bytes    int64
+
+	Latency      LatencyStats // service time
+	ResponseTime LatencyStats // from the intended start
+
+	StatusCodes  map[int]int64
+	GRPCCodes    map[string]int64
+	Errors       []ErrorCount // by count, most frequent first
+	RecentErrors []string     // latest last
+
+	Iterations        int64
+	IterationDuration LatencyStats
+
+	Streams        int64
+	StreamErrors   int64
+	StreamMessages int64
+	StreamSetup    LatencyStats
+	MessageLatency LatencyStats
+
+	HTTP HTTPPhases
+}
+
+// Elapsed returns the time covered by the snapshot
+func (s Snapshot) Elapsed() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Aggregator accumulates every result of a run in histograms, so its memory
+// does not grow with the number of results. It is safe for concurrent use.
+type Aggregator struct {
+	config *parser.Config
+	start  time.Time
+	series *timeseries.Series
+
+	mu           sync.Mutex
+	end          time.Time
+	aborted      string
+	requests     int64
+	failed       int64
+	bytes        int64
+	latencies    *hdr.Histogram
+	responses    *hdr.Histogram
+	iterations   *hdr.Histogram
+	setups       *hdr.Histogram
+	messages     *hdr.Histogram
+	sent         int64
+	streams      int64
+	streamErrors int64
+	statusCodes  map[int]int64
+	grpcCodes    map[string]int64
+	errors       map[string]int64
+	recentErrors []string
+	labeled      map[Labels]*labeled
+
+	// HTTP request phases
+	traced   int64
+	reused   int64
+	dns      *hdr.Histogram
+	connect  *hdr.Histogram
+	tls      *hdr.Histogram
+	ttfb     *hdr.Histogram
+	transfer *hdr.Histogram
+}
+
+// New creates an aggregator for a run that started at start
+func New(cfg *parser.Config, start time.Time) *Aggregator {
+	var interval time.Duration
+	if cfg.App != nil {
+		interval = cfg.App.Interval
+	}
+
+	return &Aggregator{
+		config:      cfg,
+		start:       start,
+		end:         start,
+		series:      timeseries.New(start, interval),
+		latencies:   hdr.New(latencyDigits),
+		responses:   hdr.New(latencyDigits),
+		iterations:  hdr.New(latencyDigits),
+		setups:      hdr.New(latencyDigits),
+		messages:    hdr.New(latencyDigits),
+		dns:         hdr.New(latencyDigits),
+		connect:     hdr.New(latencyDigits),
+		tls:         hdr.New(latencyDigits),
+		ttfb:        hdr.New(latencyDigits),
+		transfer:    hdr.New(latencyDigits),
+		statusCodes: make(map[int]int64),
+		grpcCodes:   make(map[string]int64),
+		errors:      make(map[string]int64),
+		labeled:     make(map[Labels]*labeled),
+	}
+}
+
+// Add records a single result
+func (a *Aggregator) Add(result loadtest.Result) {
+	a.series.Add(result)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if done := result.Timestamp.Add(result.Latency); done.After(a.end) {
+		a.end = done
+	}
+
+	switch result.Kind {
+	case loadtest.KindIteration:
+		a.iterations.Record(result.Latency)
+		return
+	case loadtest.KindStreamSetup:
+		a.setups.Record(result.Latency)
+		return
+	case loadtest.KindStreamMessage:
+		a.messages.Record(result.Latency)
+		a.bytes += result.Bytes
+		return
+	case loadtest.KindStreamSend:
+		a.sent++
+		return
+	case loadtest.KindStreamEnd:
+		a.streams++
+		a.bytes += result.Bytes
+		a.grpcCodes[result.GRPCCode]++
+		if result.Error != nil {
+			a.streamErrors++
+			a.addError(result.Error)
+		}
+		return
+	}
+
+	a.requests++
+	a.bytes += result.Bytes
+	a.addLabeled(result)
+
+	if result.Status > 0 {
+		a.statusCodes[result.Status]++
+	}
+	if result.GRPCCode != "" {
+		a.grpcCodes[result.GRPCCode]++
+	}
+
+	if result.Error != nil {
+		a.failed++
+		a.addError(result.Error)
+		return
+	}
+
+	a.latencies.Record(result.Latency)
+	a.responses.Record(result.ResponseTime)
+
+	if result.HTTP.Traced() {
+		a.addPhases(result.HTTP)
+	}
+}
+
+func (a *Aggregator) addPhases(t loadtest.HTTPTiming) {
+	a.traced++
+	if t.Reused {
+		a.reused++
+	}
+
+	// Connection phases only count when they happened
+	if t.DNS > 0 {
+		a.dns.Record(t.DNS)
+	}
+	if t.Connect > 0 {
+		a.connect.Record(t.Connect)
+	}
+	if t.TLS > 0 {
+		a.tls.Record(t.TLS)
+	}
+	a.ttfb.Record(t.TTFB)
+	a.transfer.Record(t.Transfer)
+}
+
+func (a *Aggregator) addError(err error) {
+	msg := err.Error()
+
+	if len(a.recentErrors) >= maxRecentErrors {
+		a.recentErrors = a.recentErrors[1:]
+	}
+	a.recentErrors = append(a.recentErrors, msg)
+
+	if _, ok := a.errors[msg]; !ok && len(a.errors) >= maxDistinctErrors {
+		msg = otherErrors
+	}
+	a.errors[msg]++
+}
+
+// Abort marks the run as stopped early for the given reason
+func (a *Aggregator) Abort(reason string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.aborted = reason
+}
+
+// Config returns the configuration of the run
+func (a *Aggregator) Config() *parser.Config {
+	return a.config
+}
+
+// Start returns the moment the run started
+func (a *Aggregator) Start() time.Time {
+	return a.start
+}
+
+// Series returns the time series of the run
+func (a *Aggregator) Series() *timeseries.Series {
+	return a.series
+}
+
+// Snapshot returns the current state of the run
+func (a *Aggregator) Snapshot() Snapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return Snapshot{
+		Start:             a.start,
+		End:               a.end,
+		Aborted:           a.aborted,
+		Requests:          a.requests,
+		Failed:            a.failed,
+		Bytes:             a.bytes,
+		Latency:           LatencyStatsOf(a.latencies),
+		ResponseTime:      LatencyStatsOf(a.responses),
+		StatusCodes:       copyMap(a.statusCodes),
+		GRPCCodes:         copyMap(a.grpcCodes),
+		Errors:            sortedErrors(a.errors),
+		RecentErrors:      append([]string(nil), a.recentErrors...),
+		Iterations:        a.iterations.Count(),
+		IterationDuration: LatencyStatsOf(a.iterations),
+		Streams:           a.streams,
+		StreamErrors:      a.streamErrors,
+		StreamMessages:    a.messages.Count() + a.sent,
+		StreamSetup:       LatencyStatsOf(a.setups),
+		MessageLatency:    LatencyStatsOf(a.messages),
+		HTTP: HTTPPhases{
+			Requests: a.traced,
+			Reused:   a.reused,
+			DNS:      LatencyStatsOf(a.dns),
+			Connect:  LatencyStatsOf(a.connect),
+			TLS:      LatencyStatsOf(a.tls),
+			TTFB:     LatencyStatsOf(a.ttfb),
+			Transfer: LatencyStatsOf(a.transfer),
+		},
+	}
+}
+
+// Latencies returns a copy of the service time histogram
+func (a *Aggregator) Latencies() *hdr.Histogram {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	h := hdr.New(latencyDigits)
+	h.Merge(a.latencies)
+	return h
+}
+
+// LatencyStatsOf summarizes a histogram
+func LatencyStatsOf(h *hdr.Histogram) LatencyStats {
+	if h.Count() == 0 {
+		return LatencyStats{}
+	}
+
+	return LatencyStats{
+		Min:  h.Min(),
+		Mean: h.Mean(),
+		P50:  h.Percentile(50),
+		P90:  h.Percentile(90),
+		P95:  h.Percentile(95),
+		P99:  h.Percentile(99),
+		Max:  h.Max(),
+	}
+}
+
+// sortedErrors orders the error breakdown by count
+func sortedErrors(errors map[string]int64) []ErrorCount {
+	result := make([]ErrorCount, 0, len(errors))
+	for msg, count := range errors {
+		result = append(result, ErrorCount{Message: msg, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count == result[j].Count {
+			return result[i].Message < result[j].Message
+		}
+		return result[i].Count > result[j].Count
+	})
+
+	return result
+}
+
+func copyMap[K comparable](m map[K]int64) map[K]int64 {
+	result := make(map[K]int64, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
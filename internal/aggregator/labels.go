@@ -0,0 +1,104 @@
+package aggregator
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/paniccaaa/stresstea/internal/hdr"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+)
+
+// StatusError is the status label of requests that failed without a response
+const StatusError = "error"
+
+// Labels identify a group of requests in the per-label breakdown
+type Labels struct {
+	Scenario string
+	Step     string
+	Status   string // HTTP status, gRPC code name or StatusError
+}
+
+// LabeledStats are the metrics of the requests sharing a label set
+type LabeledStats struct {
+	Labels
+	Requests int64
+	Errors   int64
+	Bytes    int64
+	Latency  *hdr.Histogram // service time of successful requests, a copy
+}
+
+// labeled accumulates one label set
+type labeled struct {
+	requests  int64
+	errors    int64
+	bytes     int64
+	latencies *hdr.Histogram
+}
+
+// labelsOf returns the labels of a request result
+func labelsOf(result loadtest.Result) Labels {
+	labels := Labels{Scenario: result.Scenario, Step: result.Step}
+
+	switch {
+	case result.Status > 0:
+		labels.Status = strconv.Itoa(result.Status)
+	case result.GRPCCode != "":
+		labels.Status = result.GRPCCode
+	default:
+		labels.Status = StatusError
+	}
+
+	return labels
+}
+
+// addLabeled records a request result in its label set. The caller holds mu.
+func (a *Aggregator) addLabeled(result loadtest.Result) {
+	labels := labelsOf(result)
+	l, ok := a.labeled[labels]
+	if !ok {
+		l = &labeled{latencies: hdr.New(labeledDigits)}
+		a.labeled[labels] = l
+	}
+
+	l.requests++
+	l.bytes += result.Bytes
+	if result.Error != nil {
+		l.errors++
+		return
+	}
+	l.latencies.Record(result.Latency)
+}
+
+// ByLabels returns the requests broken down by scenario, step and status,
+// ordered by labels
+func (a *Aggregator) ByLabels() []LabeledStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result := make([]LabeledStats, 0, len(a.labeled))
+	for labels, l := range a.labeled {
+		latencies := hdr.New(labeledDigits)
+		latencies.Merge(l.latencies)
+
+		result = append(result, LabeledStats{
+			Labels:   labels,
+			Requests: l.requests,
+			Errors:   l.errors,
+			Bytes:    l.bytes,
+			Latency:  latencies,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		x, y := result[i].Labels, result[j].Labels
+		if x.Scenario != y.Scenario {
+			return x.Scenario < y.Scenario
+		}
+		if x.Step != y.Step {
+			return x.Step < y.Step
+		}
+		return x.Status < y.Status
+	})
+
+	return result
+}
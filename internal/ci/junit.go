@@ -0,0 +1,132 @@
+// Package ci renders the outcome of a run for CI systems: a JUnit XML report
+// where every threshold is a test case and a Markdown summary ready to be
+// posted as a pull request comment.
+package ci
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/paniccaaa/stresstea/internal/report"
+	"github.com/paniccaaa/stresstea/internal/threshold"
+)
+
+// junitSuites mirrors the JUnit XML format understood by Jenkins, GitLab,
+// GitHub Actions reporters and the like
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Name    string       `xml:"name,attr"`
+	Tests   int          `xml:"tests,attr"`
+	Failed  int          `xml:"failures,attr"`
+	Time    string       `xml:"time,attr"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failed     int             `xml:"failures,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Output    string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit renders a JUnit XML report with a test case per threshold and
+// one for the run itself, which fails when the run was aborted
+func WriteJUnit(w io.Writer, s report.Summary, results []threshold.Result) error {
+	duration := strconv.FormatFloat(s.Duration.Seconds(), 'f', 3, 64)
+
+	suite := junitSuite{
+		Name: "stresstea",
+		Time: duration,
+		Properties: []junitProperty{
+			{Name: "target", Value: s.Target},
+			{Name: "protocol", Value: s.Protocol},
+			{Name: "executor", Value: s.Executor},
+			{Name: "requests", Value: strconv.FormatInt(s.Requests, 10)},
+			{Name: "error_rate", Value: fmt.Sprintf("%.2f%%", s.ErrorRate)},
+			{Name: "rps", Value: fmt.Sprintf("%.1f", s.RPS)},
+		},
+	}
+	if s.Arrival != "" {
+		suite.Properties = append(suite.Properties,
+			junitProperty{Name: "arrival", Value: s.Arrival},
+			junitProperty{Name: "seed", Value: strconv.FormatUint(s.Seed, 10)},
+		)
+	}
+
+	run := junitCase{
+		Name:      "run completed",
+		ClassName: "stresstea.run",
+		Time:      duration,
+		Output:    fmt.Sprintf("%d requests, %d failed, %.1f RPS", s.Requests, s.Failed, s.RPS),
+	}
+	if s.Aborted != "" {
+		run.Failure = &junitFailure{Message: "aborted: " + s.Aborted, Type: "aborted", Text: s.Aborted}
+	}
+	suite.Cases = append(suite.Cases, run)
+
+	for _, r := range results {
+		c := junitCase{
+			Name:      r.Expr,
+			ClassName: "stresstea.thresholds",
+			Time:      "0",
+			Output:    fmt.Sprintf("%s = %s", r.Metric, r.FormatActual()),
+		}
+		if !r.Pass {
+			c.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s: %s = %s", r.Expr, r.Metric, r.FormatActual()),
+				Type:    "threshold",
+				Text:    fmt.Sprintf("threshold %q failed, measured %s", r.Expr, r.FormatActual()),
+			}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	suite.Tests = len(suite.Cases)
+	for _, c := range suite.Cases {
+		if c.Failure != nil {
+			suite.Failed++
+		}
+	}
+
+	doc := junitSuites{
+		Name:   "stresstea",
+		Tests:  suite.Tests,
+		Failed: suite.Failed,
+		Time:   duration,
+		Suites: []junitSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode junit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,137 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/report"
+	"github.com/paniccaaa/stresstea/internal/threshold"
+)
+
+// maxMarkdownErrors caps the error table of the Markdown summary
+const maxMarkdownErrors = 10
+
+// WriteMarkdown renders a summary that can be posted as-is as a pull request
+// comment: the verdict, throughput, latency percentiles, status codes,
+// thresholds and the most frequent errors
+func WriteMarkdown(w io.Writer, s report.Summary, results []threshold.Result) error {
+	var b strings.Builder
+
+	verdict := "✅ Passed"
+	if !threshold.Passed(results) || s.Aborted != "" {
+		verdict = "❌ Failed"
+	}
+	fmt.Fprintf(&b, "## Stresstea: %s\n\n", verdict)
+
+	fmt.Fprintf(&b, "`%s` (%s, %s) for %v", cell(s.Target), s.Protocol, s.Executor, s.Duration.Round(time.Millisecond))
+	if s.Arrival != "" {
+		fmt.Fprintf(&b, ", %s arrivals (seed %d)", s.Arrival, s.Seed)
+	}
+	if s.Aborted != "" {
+		fmt.Fprintf(&b, ", **aborted**: %s", cell(s.Aborted))
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString("| Requests | Failed | Error rate | RPS | Data received |\n")
+	b.WriteString("| ---: | ---: | ---: | ---: | ---: |\n")
+	rps := fmt.Sprintf("%.1f", s.RPS)
+	switch {
+	case s.TargetRPS > 0 && s.TargetIterations():
+		rps += fmt.Sprintf(" (%.1f iterations/s, target %.1f)", s.IterationsPerSecond, s.TargetRPS)
+	case s.TargetRPS > 0:
+		rps += fmt.Sprintf(" (target %.1f)", s.TargetRPS)
+	}
+	fmt.Fprintf(&b, "| %d | %d | %.2f%% | %s | %s |\n\n", s.Requests, s.Failed, s.ErrorRate, rps, report.FormatBytes(s.Bytes))
+
+	b.WriteString("### Latency\n\n")
+	b.WriteString("| | min | mean | p50 | p90 | p95 | p99 | max |\n")
+	b.WriteString("| --- | ---: | ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	writeLatencyRow(&b, "service time", s.Latency)
+	writeLatencyRow(&b, "response time", s.ResponseTime)
+	if s.Iterations > 0 {
+		writeLatencyRow(&b, "iteration", s.IterationDuration)
+	}
+	if p := s.HTTPPhases; p != nil {
+		writeLatencyRow(&b, "ttfb", p.TTFB)
+	}
+	b.WriteString("\n")
+
+	if len(s.StatusCodes) > 0 || len(s.GRPCCodes) > 0 {
+		b.WriteString("### Status codes\n\n")
+		b.WriteString("| Code | Count | Share |\n")
+		b.WriteString("| --- | ---: | ---: |\n")
+
+		codes := make([]int, 0, len(s.StatusCodes))
+		for code := range s.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(&b, "| %d | %d | %.2f%% |\n", code, s.StatusCodes[code], share(s.StatusCodes[code], s.Requests))
+		}
+
+		names := make([]string, 0, len(s.GRPCCodes))
+		for name := range s.GRPCCodes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "| %s | %d | %.2f%% |\n", cell(name), s.GRPCCodes[name], share(s.GRPCCodes[name], s.Requests))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(results) > 0 {
+		b.WriteString("### Thresholds\n\n")
+		b.WriteString("| Result | Check | Measured |\n")
+		b.WriteString("| --- | --- | ---: |\n")
+		for _, r := range results {
+			result := "✅ pass"
+			if !r.Pass {
+				result = "❌ fail"
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | %s |\n", result, cell(r.Expr), cell(r.FormatActual()))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(s.Errors) > 0 {
+		b.WriteString("### Errors\n\n")
+		b.WriteString("| Error | Count |\n")
+		b.WriteString("| --- | ---: |\n")
+		for i, e := range s.Errors {
+			if i == maxMarkdownErrors {
+				fmt.Fprintf(&b, "| %d more kinds of errors | |\n", len(s.Errors)-maxMarkdownErrors)
+				break
+			}
+			fmt.Fprintf(&b, "| %s | %d |\n", cell(e.Message), e.Count)
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeLatencyRow(b *strings.Builder, name string, l aggregator.LatencyStats) {
+	fmt.Fprintf(b, "| %s | %s | %s | %s | %s | %s | %s | %s |\n", name,
+		report.FormatDuration(l.Min), report.FormatDuration(l.Mean), report.FormatDuration(l.P50),
+		report.FormatDuration(l.P90), report.FormatDuration(l.P95), report.FormatDuration(l.P99),
+		report.FormatDuration(l.Max))
+}
+
+// cell keeps text from breaking the table: pipes are escaped and line
+// breaks flattened
+var cell = strings.NewReplacer("|", `\|`, "\r", "", "\n", " ", "`", "'").Replace
+
+// share returns part as a percentage of total
+func share(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
@@ -0,0 +1,295 @@
+// Package compare diffs two recorded runs, a baseline and a candidate, and
+// flags the metrics where the candidate is worse than the tolerances allow.
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/hdr"
+	"github.com/paniccaaa/stresstea/internal/report"
+)
+
+// ErrRegression is returned when the candidate regressed beyond the tolerances
+var ErrRegression = errors.New("candidate regressed")
+
+// Metric names
+const (
+	MetricRPS       = "rps"
+	MetricErrorRate = "error_rate"
+)
+
+// percentiles are the compared latency percentiles of the service time
+var percentiles = []struct {
+	name string
+	q    float64
+}{
+	{"p50", 50}, {"p90", 90}, {"p95", 95}, {"p99", 99},
+}
+
+// Tolerances bound how much worse the candidate may be than the baseline
+type Tolerances struct {
+	RPS        float64       `json:"rps"`         // allowed throughput drop, percent
+	ErrorRate  float64       `json:"error_rate"`  // allowed error rate increase, percentage points
+	Latency    float64       `json:"latency"`     // allowed latency percentile increase, percent
+	MinLatency time.Duration `json:"min_latency"` // latency increases below this are never regressions
+
+	// Confidence is the level of the confidence intervals and tests, e.g. 0.95
+	Confidence float64 `json:"confidence"`
+	// Significant makes latency increases regress only when they are
+	// statistically significant
+	Significant bool `json:"significant"`
+}
+
+// DefaultTolerances returns the tolerances used when none are given
+func DefaultTolerances() Tolerances {
+	return Tolerances{
+		RPS:        10,
+		ErrorRate:  1,
+		Latency:    10,
+		MinLatency: time.Millisecond,
+		Confidence: 0.95,
+	}
+}
+
+// Validate checks that the tolerances are usable
+func (t Tolerances) Validate() error {
+	if t.RPS < 0 || t.ErrorRate < 0 || t.Latency < 0 || t.MinLatency < 0 {
+		return fmt.Errorf("tolerances must not be negative")
+	}
+	if t.Confidence <= 0 || t.Confidence >= 1 {
+		return fmt.Errorf("confidence must be between 0 and 1, got %v", t.Confidence)
+	}
+	return nil
+}
+
+// Step identifies a scenario step; the zero value is the whole run
+type Step struct {
+	Scenario string `json:"scenario,omitempty"`
+	Step     string `json:"step,omitempty"`
+}
+
+// Name returns "scenario/step", or "all requests" for the whole run
+func (s Step) Name() string {
+	switch {
+	case s == Step{}:
+		return "all requests"
+	case s.Scenario == "":
+		return s.Step
+	case s.Step == "":
+		return s.Scenario
+	default:
+		return s.Scenario + "/" + s.Step
+	}
+}
+
+// group holds the requests of one step of a run
+type group struct {
+	requests int64
+	errors   int64
+	latency  *hdr.Histogram // service time of successful requests
+}
+
+// Run is a recorded run loaded for comparison
+type Run struct {
+	Path    string
+	Summary report.Summary
+
+	total group
+	steps map[Step]*group
+	order []Step // steps in label order
+}
+
+// Load replays a recorded results file
+func Load(path string) (*Run, error) {
+	agg, stats, err := report.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &Run{
+		Path:    path,
+		Summary: report.NewSummary(agg, stats),
+		steps:   make(map[Step]*group),
+	}
+	run.total = group{
+		requests: run.Summary.Requests,
+		errors:   run.Summary.Failed,
+		latency:  agg.Latencies(),
+	}
+
+	for _, l := range agg.ByLabels() {
+		key := Step{Scenario: l.Scenario, Step: l.Step}
+		g, ok := run.steps[key]
+		if !ok {
+			g = &group{latency: l.Latency}
+			run.steps[key] = g
+			run.order = append(run.order, key)
+		} else {
+			g.latency.Merge(l.Latency)
+		}
+		g.requests += l.Requests
+		g.errors += l.Errors
+	}
+
+	// A plain single-target run has a single unnamed step: the whole run
+	if len(run.order) == 1 && run.order[0] == (Step{}) {
+		run.steps, run.order = nil, nil
+	}
+
+	return run, nil
+}
+
+// Delta is the change of one metric between the runs
+type Delta struct {
+	Metric    string  `json:"metric"`
+	Baseline  float64 `json:"baseline"`  // nanoseconds for latencies, percent for the error rate
+	Candidate float64 `json:"candidate"` // same unit as Baseline
+	// Change is the relative change in percent, or the difference in
+	// percentage points for the error rate. Nil when the baseline is zero.
+	Change *float64 `json:"change,omitempty"`
+	// CI is the bootstrap confidence interval of the change of a latency
+	// percentile, nil for other metrics
+	CI         *Interval `json:"ci,omitempty"`
+	Regression bool      `json:"regression"`
+}
+
+// IsLatency reports whether the metric is a latency percentile
+func (d Delta) IsLatency() bool {
+	return d.Metric != MetricRPS && d.Metric != MetricErrorRate
+}
+
+// StepComparison holds the deltas of one step
+type StepComparison struct {
+	Step
+	// Missing names the run without this step: "baseline" or "candidate"
+	Missing string  `json:"missing,omitempty"`
+	Deltas  []Delta `json:"deltas,omitempty"`
+	// MannWhitney tests the shift of the whole latency distribution, nil
+	// when a run had no successful requests
+	MannWhitney *MannWhitney `json:"mann_whitney,omitempty"`
+}
+
+// Result is the comparison of two runs
+type Result struct {
+	Baseline   string           `json:"baseline"`
+	Candidate  string           `json:"candidate"`
+	Tolerances Tolerances       `json:"tolerances"`
+	Steps      []StepComparison `json:"steps"` // the whole run first
+}
+
+// Regressions returns the number of regressed metrics
+func (r Result) Regressions() int {
+	n := 0
+	for _, s := range r.Steps {
+		for _, d := range s.Deltas {
+			if d.Regression {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Compare diffs the candidate against the baseline
+func Compare(baseline, candidate *Run, tol Tolerances) Result {
+	result := Result{
+		Baseline:   baseline.Path,
+		Candidate:  candidate.Path,
+		Tolerances: tol,
+	}
+
+	// A fixed seed keeps the intervals identical between invocations
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	result.Steps = append(result.Steps, compareGroups(Step{}, baseline.total, candidate.total,
+		baseline.Summary.Duration, candidate.Summary.Duration, tol, rng))
+
+	steps := append([]Step(nil), baseline.order...)
+	for _, key := range candidate.order {
+		if _, ok := baseline.steps[key]; !ok {
+			steps = append(steps, key)
+		}
+	}
+
+	for _, key := range steps {
+		b, inBaseline := baseline.steps[key]
+		c, inCandidate := candidate.steps[key]
+
+		switch {
+		case !inBaseline:
+			result.Steps = append(result.Steps, StepComparison{Step: key, Missing: "baseline"})
+		case !inCandidate:
+			result.Steps = append(result.Steps, StepComparison{Step: key, Missing: "candidate"})
+		default:
+			result.Steps = append(result.Steps, compareGroups(key, *b, *c,
+				baseline.Summary.Duration, candidate.Summary.Duration, tol, rng))
+		}
+	}
+
+	return result
+}
+
+// compareGroups computes the deltas of a step
+func compareGroups(key Step, b, c group, bDuration, cDuration time.Duration, tol Tolerances, rng *rand.Rand) StepComparison {
+	rps := relative(MetricRPS, rate(b.requests, bDuration), rate(c.requests, cDuration))
+	rps.Regression = rps.Change != nil && -*rps.Change > tol.RPS
+
+	errorRate := Delta{
+		Metric:    MetricErrorRate,
+		Baseline:  percent(b.errors, b.requests),
+		Candidate: percent(c.errors, c.requests),
+	}
+	points := errorRate.Candidate - errorRate.Baseline
+	errorRate.Change = &points
+	errorRate.Regression = points > tol.ErrorRate
+
+	step := StepComparison{Step: key, Deltas: []Delta{rps, errorRate}}
+
+	// Latencies are only comparable when both runs had successful requests
+	if b.latency.Count() == 0 || c.latency.Count() == 0 {
+		return step
+	}
+
+	bDist, cDist := newDistribution(b.latency), newDistribution(c.latency)
+	for _, p := range percentiles {
+		d := relative(p.name, float64(b.latency.Percentile(p.q)), float64(c.latency.Percentile(p.q)))
+		ci := bootstrap(bDist, cDist, p.q, tol.Confidence, rng)
+		d.CI = &ci
+		d.Regression = d.Change != nil && *d.Change > tol.Latency &&
+			d.Candidate-d.Baseline > float64(tol.MinLatency) &&
+			(ci.Low > 0 || !tol.Significant)
+		step.Deltas = append(step.Deltas, d)
+	}
+
+	mw := mannWhitney(b.latency, c.latency, tol.Confidence)
+	step.MannWhitney = &mw
+
+	return step
+}
+
+// relative builds a delta whose change is relative to the baseline
+func relative(metric string, baseline, candidate float64) Delta {
+	d := Delta{Metric: metric, Baseline: baseline, Candidate: candidate}
+	if baseline > 0 {
+		change := (candidate - baseline) / baseline * 100
+		d.Change = &change
+	}
+	return d
+}
+
+func rate(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+func percent(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
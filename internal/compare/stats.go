@@ -0,0 +1,163 @@
+package compare
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/hdr"
+)
+
+// bootstrapIterations is the number of resamples behind a confidence interval
+const bootstrapIterations = 2000
+
+// Interval is a confidence interval of the change of a latency percentile,
+// candidate minus baseline, in nanoseconds
+type Interval struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+	// Significant is set when the interval excludes zero
+	Significant bool `json:"significant"`
+}
+
+// MannWhitney is the outcome of a Mann-Whitney U test between the latencies
+// of the two runs
+type MannWhitney struct {
+	U float64 `json:"u"` // of the candidate
+	Z float64 `json:"z"`
+	P float64 `json:"p"` // two-sided
+	// Slower is the probability that a random candidate request is slower
+	// than a random baseline one, 0.5 when neither run is slower
+	Slower      float64 `json:"slower"`
+	Significant bool    `json:"significant"`
+}
+
+// distribution is a latency histogram flattened for sampling
+type distribution struct {
+	values []float64 // bucket values in increasing order
+	cum    []int64   // cumulative counts
+	n      int64
+}
+
+func newDistribution(h *hdr.Histogram) distribution {
+	var d distribution
+	h.ForEach(func(value time.Duration, count int64) {
+		d.n += count
+		d.values = append(d.values, float64(value))
+		d.cum = append(d.cum, d.n)
+	})
+	return d
+}
+
+// at returns the k-th smallest sample, 1-based
+func (d distribution) at(k int64) float64 {
+	i := sort.Search(len(d.cum), func(i int) bool { return d.cum[i] >= k })
+	return d.values[min(i, len(d.values)-1)]
+}
+
+// resampledPercentile draws the q-th percentile of one bootstrap resample.
+// The k-th smallest of n draws from the samples is the sample at rank
+// ceil(U*n) with U ~ Beta(k, n-k+1), so the resample is never materialized.
+func (d distribution) resampledPercentile(q float64, rng *rand.Rand) float64 {
+	k := min(max(int64(math.Ceil(q/100*float64(d.n))), 1), d.n)
+	u := beta(rng, float64(k), float64(d.n-k+1))
+	return d.at(min(max(int64(math.Ceil(u*float64(d.n))), 1), d.n))
+}
+
+// bootstrap estimates the confidence interval of the change of the q-th
+// percentile with the percentile method
+func bootstrap(b, c distribution, q, confidence float64, rng *rand.Rand) Interval {
+	diffs := make([]float64, bootstrapIterations)
+	for i := range diffs {
+		diffs[i] = c.resampledPercentile(q, rng) - b.resampledPercentile(q, rng)
+	}
+	sort.Float64s(diffs)
+
+	tail := (1 - confidence) / 2
+	last := float64(len(diffs) - 1)
+	ci := Interval{
+		Low:  diffs[int(math.Floor(tail*last))],
+		High: diffs[int(math.Ceil((1-tail)*last))],
+	}
+	ci.Significant = ci.Low > 0 || ci.High < 0
+
+	return ci
+}
+
+// mannWhitney tests whether the latencies of one run tend to be larger than
+// those of the other. Values sharing a histogram bucket are ties; the normal
+// approximation with tie correction is used, which is accurate for the sample
+// sizes of load tests.
+func mannWhitney(b, c *hdr.Histogram, confidence float64) MannWhitney {
+	type bucket struct {
+		value     time.Duration
+		baseline  int64
+		candidate int64
+	}
+
+	var buckets []bucket
+	b.ForEach(func(value time.Duration, count int64) {
+		buckets = append(buckets, bucket{value: value, baseline: count})
+	})
+	c.ForEach(func(value time.Duration, count int64) {
+		buckets = append(buckets, bucket{value: value, candidate: count})
+	})
+	sort.SliceStable(buckets, func(i, j int) bool { return buckets[i].value < buckets[j].value })
+
+	nb, nc := float64(b.Count()), float64(c.Count())
+	n := nb + nc
+
+	// Rank sum of the candidate; tied values share their average rank
+	var rankSum, ties, seen float64
+	for i := 0; i < len(buckets); {
+		var tb, tc float64
+		j := i
+		for ; j < len(buckets) && buckets[j].value == buckets[i].value; j++ {
+			tb += float64(buckets[j].baseline)
+			tc += float64(buckets[j].candidate)
+		}
+		t := tb + tc
+		rankSum += tc * (seen + (t+1)/2)
+		ties += t*t*t - t
+		seen += t
+		i = j
+	}
+
+	u := rankSum - nc*(nc+1)/2
+	result := MannWhitney{U: u, Slower: u / (nb * nc), P: 1}
+
+	variance := nb * nc / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance > 0 {
+		result.Z = (u - nb*nc/2) / math.Sqrt(variance)
+		result.P = math.Erfc(math.Abs(result.Z) / math.Sqrt2)
+	}
+	result.Significant = result.P < 1-confidence
+
+	return result
+}
+
+// beta draws from Beta(a, b) for a, b >= 1
+func beta(rng *rand.Rand, a, b float64) float64 {
+	x := gamma(rng, a)
+	return x / (x + gamma(rng, b))
+}
+
+// gamma draws from Gamma(shape, 1) for shape >= 1 with the method of
+// Marsaglia and Tsang
+func gamma(rng *rand.Rand, shape float64) float64 {
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if u < 1-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/report"
+)
+
+// WriteText renders the comparison as a table per step
+func WriteText(w io.Writer, r Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "Stresstea comparison")
+	fmt.Fprintln(tw, strings.Repeat("=", 60))
+	fmt.Fprintf(tw, "Baseline:\t%s\n", r.Baseline)
+	fmt.Fprintf(tw, "Candidate:\t%s\n", r.Candidate)
+	fmt.Fprintf(tw, "Tolerances:\trps -%g%%, error rate +%gpp, latency +%g%% (and +%s)",
+		r.Tolerances.RPS, r.Tolerances.ErrorRate, r.Tolerances.Latency, report.FormatDuration(r.Tolerances.MinLatency))
+	if r.Tolerances.Significant {
+		fmt.Fprint(tw, ", significant latency changes only")
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "Statistics:\t%g%% bootstrap confidence intervals of the change, Mann-Whitney U test\n",
+		r.Tolerances.Confidence*100)
+
+	for _, s := range r.Steps {
+		fmt.Fprintln(tw)
+		if s.Missing != "" {
+			fmt.Fprintf(tw, "%s\tnot in %s\n", s.Name(), s.Missing)
+			continue
+		}
+
+		fmt.Fprintf(tw, "%s\tbaseline\tcandidate\tchange\tconfidence interval\t\t\n", s.Name())
+		for _, d := range s.Deltas {
+			interval, significance := "", ""
+			if d.CI != nil {
+				interval = fmt.Sprintf("[%s, %s]", formatSigned(d.CI.Low), formatSigned(d.CI.High))
+				significance = "not significant"
+				if d.CI.Significant {
+					significance = "significant"
+				}
+			}
+			mark := ""
+			if d.Regression {
+				mark = "REGRESSION"
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\n", d.Metric,
+				formatValue(d, d.Baseline), formatValue(d, d.Candidate), formatChange(d),
+				interval, significance, mark)
+		}
+		if mw := s.MannWhitney; mw != nil {
+			significance := "not significant"
+			if mw.Significant {
+				significance = "significant"
+			}
+			fmt.Fprintf(tw, "  Mann-Whitney: candidate slower in %.1f%% of request pairs, p %s (%s)\n",
+				mw.Slower*100, formatP(mw.P), significance)
+		}
+	}
+
+	fmt.Fprintln(tw)
+	if n := r.Regressions(); n > 0 {
+		fmt.Fprintf(tw, "%d metrics regressed\n", n)
+	} else {
+		fmt.Fprintln(tw, "No regressions")
+	}
+
+	return tw.Flush()
+}
+
+// WriteJSON renders the comparison as indented JSON. Durations are in nanoseconds.
+func WriteJSON(w io.Writer, r Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func formatValue(d Delta, v float64) string {
+	switch {
+	case d.Metric == MetricRPS:
+		return fmt.Sprintf("%.1f", v)
+	case d.Metric == MetricErrorRate:
+		return fmt.Sprintf("%.2f%%", v)
+	default:
+		return report.FormatDuration(time.Duration(v))
+	}
+}
+
+func formatChange(d Delta) string {
+	switch {
+	case d.Change == nil:
+		return "n/a"
+	case d.Metric == MetricErrorRate:
+		return fmt.Sprintf("%+.2fpp", *d.Change)
+	default:
+		return fmt.Sprintf("%+.2f%%", *d.Change)
+	}
+}
+
+// formatSigned formats a latency change with its sign
+func formatSigned(ns float64) string {
+	if ns < 0 {
+		return "-" + report.FormatDuration(time.Duration(-ns))
+	}
+	return "+" + report.FormatDuration(time.Duration(ns))
+}
+
+func formatP(p float64) string {
+	if p < 0.0001 {
+		return "< 0.0001"
+	}
+	return fmt.Sprintf("= %.4f", p)
+}
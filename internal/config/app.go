@@ -1,9 +1,33 @@
 package config
 
+import "time"
+
 // AppConfig holds the complete application configuration
 type AppConfig struct {
 	Logger *LoggerConfig `yaml:"logger"`
 	TUI    *TUIConfig    `yaml:"tui"`
+
+	// Output is the file raw results are recorded to, empty = no recording
+	Output string `yaml:"output,omitempty"`
+	// Report is the HTML report written at the end of the run, empty = none
+	Report string `yaml:"report,omitempty"`
+	// JUnit is the JUnit XML report of the thresholds written at the end of
+	// the run, empty = none
+	JUnit string `yaml:"junit,omitempty"`
+	// Markdown is the pull request ready summary written at the end of the
+	// run, empty = none
+	Markdown string `yaml:"markdown,omitempty"`
+	// Interval is the width of the metrics time-series buckets
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// MetricsAddr is where Prometheus metrics are served, empty = nowhere
+	MetricsAddr string `yaml:"metrics_addr,omitempty"`
+	// OTLP pushes metrics and spans to an OpenTelemetry collector, nil = off
+	OTLP *OTLPConfig `yaml:"otlp,omitempty"`
+	// Streams periodically receive aggregated metrics, e.g. InfluxDB or StatsD
+	Streams []StreamConfig `yaml:"streams,omitempty"`
+	// RunID tags the metrics of the run in external systems, empty = derived
+	// from the start time
+	RunID string `yaml:"run_id,omitempty"`
 }
 
 // DefaultAppConfig returns default application configuration
@@ -15,6 +39,7 @@ func DefaultAppConfig() *AppConfig {
 			OutputPath: "stdout",
 			ErrorPath:  "stderr",
 		},
-		TUI: DefaultTUIConfig(),
+		TUI:      DefaultTUIConfig(),
+		Interval: time.Second,
 	}
 }
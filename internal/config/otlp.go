@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+// OTLPConfig holds OpenTelemetry export configuration
+type OTLPConfig struct {
+	// Endpoint is the base URL of an OTLP/HTTP receiver, e.g. http://localhost:4318
+	Endpoint string `yaml:"endpoint"`
+	// Headers are sent with every export, e.g. for authentication
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Interval is how often metrics and spans are pushed
+	Interval time.Duration `yaml:"interval" default:"10s"`
+	// SampleRate is the share of HTTP requests traced with a client span
+	// and a W3C traceparent header, 0 disables tracing
+	SampleRate float64 `yaml:"sample_rate" default:"0"`
+	// ServiceName is reported as the service.name resource attribute
+	ServiceName string `yaml:"service_name" default:"stresstea"`
+}
+
+// DefaultOTLPConfig returns default OpenTelemetry export configuration
+func DefaultOTLPConfig() *OTLPConfig {
+	return &OTLPConfig{
+		Interval:    10 * time.Second,
+		ServiceName: "stresstea",
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Streaming output types
+const (
+	StreamInfluxDB = "influxdb"
+	StreamStatsD   = "statsd"
+)
+
+// Default addresses of streaming outputs given without one
+const (
+	DefaultInfluxDBURL   = "http://localhost:8086/write?db=stresstea"
+	DefaultStatsDAddress = "localhost:8125"
+)
+
+// StreamConfig is a streaming output that periodically receives the
+// aggregated metrics of the run
+type StreamConfig struct {
+	// Type is influxdb (line protocol over HTTP) or statsd (UDP)
+	Type string `yaml:"type"`
+	// Address is the InfluxDB write URL or the StatsD host:port
+	Address string `yaml:"address,omitempty"`
+}
+
+// ParseStream parses an --out value such as
+// "influxdb=http://localhost:8086/write?db=stresstea" or "statsd=localhost:8125".
+// The address may be omitted to use the default one.
+func ParseStream(spec string) (StreamConfig, error) {
+	kind, address, _ := strings.Cut(spec, "=")
+	stream := StreamConfig{Type: strings.ToLower(strings.TrimSpace(kind)), Address: strings.TrimSpace(address)}
+
+	switch stream.Type {
+	case StreamInfluxDB:
+		if stream.Address == "" {
+			stream.Address = DefaultInfluxDBURL
+		}
+	case StreamStatsD:
+		if stream.Address == "" {
+			stream.Address = DefaultStatsDAddress
+		}
+	default:
+		return StreamConfig{}, fmt.Errorf("unknown output %q, expected influxdb or statsd", kind)
+	}
+
+	return stream, nil
+}
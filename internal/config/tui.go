@@ -1,17 +1,24 @@
 package config
 
+import "time"
+
 // TUIConfig holds TUI configuration
 type TUIConfig struct {
 	RefreshRate int    `yaml:"refresh_rate" default:"100"` // milliseconds
 	Theme       string `yaml:"theme" default:"default"`    // default, dark, light
 	ShowHelp    bool   `yaml:"show_help" default:"true"`
+
+	// Headless disables the TUI: progress goes to stderr, the summary to stdout
+	Headless         bool          `yaml:"headless" default:"false"`
+	ProgressInterval time.Duration `yaml:"progress_interval" default:"5s"`
 }
 
 // DefaultTUIConfig returns default TUI configuration
 func DefaultTUIConfig() *TUIConfig {
 	return &TUIConfig{
-		RefreshRate: 100,
-		Theme:       "default",
-		ShowHelp:    true,
+		RefreshRate:      100,
+		Theme:            "default",
+		ShowHelp:         true,
+		ProgressInterval: 5 * time.Second,
 	}
 }
@@ -3,69 +3,134 @@ package engine
 import (
 	"context"
 	"fmt"
+	"os"
+	"time"
 
-	"github.com/paniccaaa/stresstea/internal/config"
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/exporter"
 	"github.com/paniccaaa/stresstea/internal/loadtest"
 	"github.com/paniccaaa/stresstea/internal/parser"
+	"github.com/paniccaaa/stresstea/internal/recorder"
+	"github.com/paniccaaa/stresstea/internal/report"
+	"github.com/paniccaaa/stresstea/internal/threshold"
 	"github.com/paniccaaa/stresstea/internal/ui"
 	"go.uber.org/zap"
 )
 
 type Engine struct {
-	config *parser.Config
-	logger *zap.Logger
+	config     *parser.Config
+	logger     *zap.Logger
+	thresholds []threshold.Threshold
+	recorder   *recorder.Recorder
+	otlp       *exporter.OTLP // nil unless spans are exported
+	start      time.Time
+	logPath    string // log file used while the TUI owns the terminal
 }
 
 func Run(cfg *parser.Config) error {
 	// Настраиваем runtime перед началом работы
 	cfg.SetupRuntime()
 
-	var logger *zap.Logger
-	var err error
-
-	if cfg.App != nil && cfg.App.Logger != nil {
-		logger, err = config.NewLogger(cfg.App.Logger)
-	} else {
-		logger, err = config.NewDevelopmentLogger()
+	thresholds, err := threshold.ParseAll(cfg.Test)
+	if err != nil {
+		return err
 	}
 
+	logger, logPath, err := newLogger(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
 	engine := &Engine{
-		config: cfg,
-		logger: logger,
+		config:     cfg,
+		logger:     logger,
+		logPath:    logPath,
+		thresholds: thresholds,
+	}
+	defer engine.reportLogFile()
+
+	tester, err := engine.newTester()
+	if err != nil {
+		return fmt.Errorf("failed to create tester: %w", err)
+	}
+
+	engine.start = time.Now()
+	if err := engine.openRecorder(); err != nil {
+		return err
+	}
+	// A completed run closes the file with its summary; this only finishes
+	// the file of a run that failed on the way, so it is still readable
+	defer engine.abandonRecorder()
+
+	// Results are aggregated here, not in the TUI, so pausing or closing
+	// the TUI does not affect what thresholds, recording, reports and
+	// exporters see
+	agg := aggregator.New(cfg, engine.start)
+
+	stopExporters, err := engine.startExporters(agg, tester)
+	if err != nil {
+		return err
+	}
+	defer stopExporters()
+
+	if cfg.Headless() {
+		return engine.runHeadless(tester, agg)
 	}
 
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	results := make(chan loadtest.Result, 1000)
 
 	// Start load testing in background
 	go func() {
-		if err := engine.runLoadTest(ctx, results); err != nil {
+		if err := engine.runLoadTest(ctx, tester, results); err != nil {
 			logger.Error("load test failed", zap.Error(err))
 		}
 	}()
 
-	compactTUI := ui.NewCompactTUI(cfg)
-	return compactTUI.Run(results)
-}
-func (e *Engine) runLoadTest(ctx context.Context, results chan<- loadtest.Result) error {
-	var tester loadtest.LoadTester
-	var err error
+	done := engine.aggregate(results, agg)
 
-	switch e.config.Test.Protocol {
-	case "http":
-		tester, err = loadtest.NewHTTPTester(e.config)
-	default:
-		return fmt.Errorf("unsupported protocol: %s", e.config.Test.Protocol)
+	compactTUI := ui.NewCompactTUI(cfg, tester, agg)
+	compactTUI.WatchAbort(engine.watchThresholds(ctx, cancel, agg, tester))
+
+	if err := compactTUI.Run(done); err != nil {
+		// Wait for the producers so nothing records into a closing file
+		cancel()
+		<-done
+		return err
 	}
 
-	if err != nil {
-		return fmt.Errorf("failed to create tester: %w", err)
+	cancel()
+	<-done
+	summary := report.NewSummary(agg, tester.Stats())
+
+	engine.finishOutputs(summary)
+	if len(thresholds) == 0 && cfg.App.Output == "" && cfg.App.Report == "" &&
+		cfg.App.JUnit == "" && cfg.App.Markdown == "" {
+		return nil
 	}
 
+	if err := report.WriteText(os.Stdout, summary); err != nil {
+		return err
+	}
+	return engine.checkThresholds(summary)
+}
+
+// newTester picks the tester matching the configuration
+func (e *Engine) newTester() (loadtest.LoadTester, error) {
+	switch {
+	case len(e.config.Test.Scenarios) > 0:
+		return loadtest.NewScenarioTester(e.config)
+	case e.config.Test.Protocol == "http":
+		return loadtest.NewHTTPTester(e.config)
+	case e.config.Test.Protocol == "grpc":
+		return loadtest.NewGRPCTester(e.config)
+	default:
+		return nil, fmt.Errorf("unsupported protocol: %s", e.config.Test.Protocol)
+	}
+}
+
+func (e *Engine) runLoadTest(ctx context.Context, tester loadtest.LoadTester, results chan<- loadtest.Result) error {
 	done := make(chan bool)
 
 	go func() {
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/exporter"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"go.uber.org/zap"
+)
+
+// startExporters starts the configured exporters. The returned function stops
+// them once the run is over.
+func (e *Engine) startExporters(agg *aggregator.Aggregator, tester loadtest.LoadTester) (func(), error) {
+	var stops []func() error
+	stopAll := func() {
+		for _, stop := range stops {
+			if err := stop(); err != nil {
+				e.logger.Error("failed to stop exporter", zap.Error(err))
+			}
+		}
+	}
+
+	if addr := e.config.App.MetricsAddr; addr != "" {
+		prometheus := exporter.NewPrometheus(agg, tester, e.logger)
+		if err := prometheus.Listen(addr); err != nil {
+			return nil, err
+		}
+		e.logger.Info("serving prometheus metrics", zap.String("addr", addr))
+		stops = append(stops, prometheus.Close)
+	}
+
+	runID := e.runID()
+
+	if otlp := e.config.App.OTLP; otlp != nil && otlp.Endpoint != "" {
+		e.otlp = exporter.NewOTLP(agg, tester, runID, otlp, e.logger)
+		e.otlp.Start()
+		e.logger.Info("exporting metrics over otlp", zap.String("endpoint", otlp.Endpoint))
+		stops = append(stops, e.otlp.Close)
+	}
+
+	for _, cfg := range e.config.App.Streams {
+		stream, err := exporter.NewStream(cfg, agg, tester, runID, e.config.App.Interval, e.logger)
+		if err != nil {
+			stopAll()
+			return nil, err
+		}
+		stream.Start()
+		e.logger.Info("streaming metrics", zap.String("output", cfg.Type),
+			zap.String("address", cfg.Address), zap.String("run_id", runID))
+		stops = append(stops, stream.Close)
+	}
+
+	return stopAll, nil
+}
+
+// runID identifies the run in external systems
+func (e *Engine) runID() string {
+	if id := e.config.App.RunID; id != "" {
+		return id
+	}
+	return e.start.UTC().Format("20060102T150405Z")
+}
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"github.com/paniccaaa/stresstea/internal/report"
+	"go.uber.org/zap"
+)
+
+// runHeadless runs the test without the TUI. It prints a progress line to
+// stderr every progress interval and the final summary to stdout.
+func (e *Engine) runHeadless(tester loadtest.LoadTester, agg *aggregator.Aggregator) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e.watchThresholds(ctx, cancel, agg, tester)
+
+	results := make(chan loadtest.Result, 1000)
+	go func() {
+		if err := e.runLoadTest(ctx, tester, results); err != nil {
+			e.logger.Error("load test failed", zap.Error(err))
+		}
+	}()
+	done := e.aggregate(results, agg)
+
+	interval := e.config.App.TUI.ProgressInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	var lastRequests int64
+	lastTick := start
+
+	for {
+		select {
+		case <-done:
+			summary := report.NewSummary(agg, tester.Stats())
+			e.finishOutputs(summary)
+			if err := report.WriteText(os.Stdout, summary); err != nil {
+				return err
+			}
+			return e.checkThresholds(summary)
+		case now := <-ticker.C:
+			snap := agg.Snapshot()
+			rps := float64(snap.Requests-lastRequests) / now.Sub(lastTick).Seconds()
+			lastRequests, lastTick = snap.Requests, now
+
+			stats := tester.Stats()
+			fmt.Fprintln(os.Stderr, report.ProgressLine(now.Sub(start), e.config.Test.Duration,
+				snap.Requests, snap.Failed, rps, stats.InFlight, stats.Concurrency))
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paniccaaa/stresstea/internal/config"
+	"github.com/paniccaaa/stresstea/internal/parser"
+	"go.uber.org/zap"
+)
+
+// newLogger builds the logger of a run. The terminal's streams are taken:
+// stdout carries the summary in headless mode, and the TUI redraws the
+// whole screen otherwise, so exporter errors and other messages logged
+// there would garble it. While the TUI runs, they go to a file instead;
+// its path is returned, empty when logging goes elsewhere.
+func newLogger(cfg *parser.Config) (*zap.Logger, string, error) {
+	if cfg.App == nil || cfg.App.Logger == nil {
+		logger, err := config.NewDevelopmentLogger()
+		return logger, "", err
+	}
+
+	loggerCfg := *cfg.App.Logger
+	if cfg.Headless() {
+		loggerCfg.OutputPath = "stderr"
+		logger, err := config.NewLogger(&loggerCfg)
+		return logger, "", err
+	}
+
+	var logPath string
+	for _, path := range []*string{&loggerCfg.OutputPath, &loggerCfg.ErrorPath} {
+		if *path == "stdout" || *path == "stderr" {
+			logPath = filepath.Join(os.TempDir(), fmt.Sprintf("stresstea-%d.log", os.Getpid()))
+			*path = logPath
+		}
+	}
+
+	logger, err := config.NewLogger(&loggerCfg)
+	return logger, logPath, err
+}
+
+// reportLogFile points to the log file written while the TUI was running,
+// or removes it when nothing was logged
+func (e *Engine) reportLogFile() {
+	if e.logPath == "" {
+		return
+	}
+	_ = e.logger.Sync()
+
+	info, err := os.Stat(e.logPath)
+	if err != nil {
+		return
+	}
+	if info.Size() == 0 {
+		os.Remove(e.logPath)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Messages logged during the run: %s\n", e.logPath)
+}
@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/ci"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"github.com/paniccaaa/stresstea/internal/parser"
+	"github.com/paniccaaa/stresstea/internal/recorder"
+	"github.com/paniccaaa/stresstea/internal/report"
+	"github.com/paniccaaa/stresstea/internal/threshold"
+	"go.uber.org/zap"
+)
+
+// openRecorder starts recording raw results when an output file is configured
+func (e *Engine) openRecorder() error {
+	if e.config.App == nil || e.config.App.Output == "" {
+		return nil
+	}
+
+	rec, err := recorder.Create(e.config.App.Output, recorder.Header{
+		Start:  e.start,
+		Config: recordedConfig(e.config),
+	})
+	if err != nil {
+		return err
+	}
+
+	e.recorder = rec
+	return nil
+}
+
+// abandonRecorder closes the results file of a run that ended with an error
+// before its summary was built. It is a no-op once closeRecorder has run.
+func (e *Engine) abandonRecorder() {
+	if e.recorder == nil {
+		return
+	}
+
+	if err := e.recorder.Close(recorder.Trailer{End: time.Now(), Aborted: "the run failed"}); err != nil {
+		e.logger.Error("failed to close results file", zap.Error(err))
+	}
+	e.recorder = nil
+}
+
+// recordedConfig is the configuration stored in results files. Exporter
+// settings are left out: they are not needed to replay a run and may hold
+// credentials, such as OTLP headers or InfluxDB URLs, that must not travel
+// with the file.
+func recordedConfig(cfg *parser.Config) *parser.Config {
+	recorded := *cfg
+	if cfg.App != nil {
+		app := *cfg.App
+		app.OTLP = nil
+		app.Streams = nil
+		app.MetricsAddr = ""
+		recorded.App = &app
+	}
+
+	return &recorded
+}
+
+// aggregate feeds every result to the aggregator and the recorder until the
+// results channel is closed, then closes the returned channel. Stopping the
+// run stops the producers only: results of requests that completed before
+// they noticed are still counted.
+func (e *Engine) aggregate(results <-chan loadtest.Result, agg *aggregator.Aggregator) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for result := range results {
+			e.consume(agg, result)
+		}
+	}()
+
+	return done
+}
+
+// consume hands a result to the aggregator and the recorder
+func (e *Engine) consume(agg *aggregator.Aggregator, result loadtest.Result) {
+	agg.Add(result)
+	if result.Trace != nil && e.otlp != nil {
+		e.otlp.AddSpan(result)
+	}
+
+	if e.recorder == nil {
+		return
+	}
+	if err := e.recorder.Record(result); err != nil {
+		// A broken results file must not stop the test
+		e.logger.Error("failed to record result, recording stopped", zap.Error(err))
+		e.recorder.Close(recorder.Trailer{End: time.Now()})
+		e.recorder = nil
+	}
+}
+
+// finishOutputs closes the results file and writes the end-of-run reports.
+// Failures are logged: the run itself has already completed.
+func (e *Engine) finishOutputs(summary report.Summary) {
+	if err := e.closeRecorder(summary); err != nil {
+		e.logger.Error("failed to close results file", zap.Error(err))
+	}
+
+	results := threshold.Evaluate(e.thresholds, summary)
+	outputs := []struct {
+		path  string
+		write func(w io.Writer) error
+	}{
+		{e.config.App.Report, func(w io.Writer) error { return report.WriteHTML(w, summary) }},
+		{e.config.App.JUnit, func(w io.Writer) error { return ci.WriteJUnit(w, summary, results) }},
+		{e.config.App.Markdown, func(w io.Writer) error { return ci.WriteMarkdown(w, summary, results) }},
+	}
+	for _, output := range outputs {
+		if output.path == "" {
+			continue
+		}
+		if err := writeFile(output.path, output.write); err != nil {
+			e.logger.Error("failed to write report", zap.String("path", output.path), zap.Error(err))
+		}
+	}
+}
+
+// writeFile creates path and renders a report into it
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// closeRecorder finishes the results file with the final state of the run
+func (e *Engine) closeRecorder(summary report.Summary) error {
+	if e.recorder == nil {
+		return nil
+	}
+
+	err := e.recorder.Close(recorder.Trailer{
+		End:       time.Now(),
+		Scheduler: summary.Scheduler,
+		Aborted:   summary.Aborted,
+	})
+	if dropped := e.recorder.Dropped(); dropped > 0 {
+		e.logger.Warn("results file writer fell behind, results were not recorded", zap.Int64("dropped", dropped))
+	}
+	e.recorder = nil
+
+	return err
+}
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"github.com/paniccaaa/stresstea/internal/report"
+	"github.com/paniccaaa/stresstea/internal/threshold"
+	"go.uber.org/zap"
+)
+
+// abortCheckInterval is how often abort_on_fail thresholds are evaluated
+const abortCheckInterval = time.Second
+
+// checkThresholds prints the pass/fail table and reports broken thresholds
+func (e *Engine) checkThresholds(summary report.Summary) error {
+	if len(e.thresholds) == 0 {
+		return nil
+	}
+
+	results := threshold.Evaluate(e.thresholds, summary)
+	if err := threshold.WriteText(os.Stdout, results); err != nil {
+		return err
+	}
+
+	if !threshold.Passed(results) || summary.Aborted != "" {
+		return threshold.ErrFailed
+	}
+
+	return nil
+}
+
+// watchThresholds evaluates abort_on_fail thresholds while the test runs. When
+// one of them fails after its grace period, the run is marked as aborted and
+// stopped through cancel; the reason is also sent on the returned channel.
+func (e *Engine) watchThresholds(ctx context.Context, cancel context.CancelFunc, agg *aggregator.Aggregator, tester loadtest.LoadTester) <-chan string {
+	aborted := make(chan string, 1)
+
+	var watched []threshold.Threshold
+	for _, t := range e.thresholds {
+		if t.AbortOnFail {
+			watched = append(watched, t)
+		}
+	}
+	if len(watched) == 0 {
+		return aborted
+	}
+
+	go func() {
+		ticker := time.NewTicker(abortCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				elapsed := now.Sub(agg.Start())
+				summary := report.NewSummary(agg, tester.Stats())
+
+				for _, t := range watched {
+					if elapsed < t.Grace {
+						continue
+					}
+
+					// A threshold without a value yet (nothing scheduled,
+					// no requests) is not a failure during the run
+					result := t.Evaluate(summary)
+					if result.Pass || result.Note != "" {
+						continue
+					}
+
+					reason := fmt.Sprintf("threshold '%s' failed (%s)", t.Expr, result.FormatActual())
+					e.logger.Warn("aborting run", zap.String("reason", reason))
+					agg.Abort(reason)
+					aborted <- reason
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return aborted
+}
@@ -0,0 +1,49 @@
+// Package exporter publishes the metrics of a running test to external
+// monitoring systems. Exporters read the engine's aggregator, so they see the
+// same numbers as the TUI and the reports.
+package exporter
+
+import (
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/hdr"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"github.com/paniccaaa/stresstea/internal/parser"
+)
+
+// latencyBuckets are the upper bounds of the latency histograms, in seconds
+var latencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// bucketCounts spreads h over latencyBuckets. The result has one more entry
+// than latencyBuckets for values above the last bound.
+func bucketCounts(h *hdr.Histogram) []int64 {
+	counts := make([]int64, len(latencyBuckets)+1)
+	h.ForEach(func(value time.Duration, count int64) {
+		i := 0
+		for i < len(latencyBuckets) && value.Seconds() > latencyBuckets[i] {
+			i++
+		}
+		counts[i] += count
+	})
+
+	return counts
+}
+
+// rateProfile returns the target rate profile of open-model runs, nil for
+// closed-model ones
+func rateProfile(agg *aggregator.Aggregator) *loadtest.RateProfile {
+	if cfg := agg.Config().Test; cfg.Executor != parser.ExecutorVUs {
+		return loadtest.NewRateProfile(cfg)
+	}
+	return nil
+}
+
+// achievedRate is the request rate over the last completed interval
+func achievedRate(agg *aggregator.Aggregator, now time.Time) float64 {
+	series := agg.Series()
+	if points := series.Completed(now, 1); len(points) > 0 {
+		return series.Rate(points[0])
+	}
+	return 0
+}
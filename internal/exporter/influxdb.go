@@ -0,0 +1,117 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// influxTokenEnv holds the API token for InfluxDB 2.x write endpoints
+const influxTokenEnv = "STRESSTEA_INFLUXDB_TOKEN"
+
+// influxWriter posts flushes in the InfluxDB line protocol, one
+// stresstea_requests line per label set and one stresstea_run line
+type influxWriter struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func newInfluxWriter(address string) (*influxWriter, error) {
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid influxdb url %q, expected e.g. http://localhost:8086/write?db=stresstea", address)
+	}
+
+	return &influxWriter{
+		url:    address,
+		token:  os.Getenv(influxTokenEnv),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (w *influxWriter) write(f flush) error {
+	var buf bytes.Buffer
+	ts := strconv.FormatInt(f.time.UnixNano(), 10)
+
+	for _, g := range f.groups {
+		buf.WriteString("stresstea_requests")
+		writeInfluxTags(&buf, append([]string{"run_id", f.runID}, g.tags()...))
+		fmt.Fprintf(&buf, " requests=%di,errors=%di,bytes=%di", g.requests, g.errors, g.bytes)
+		if g.count > 0 {
+			fmt.Fprintf(&buf, ",min_ms=%s,mean_ms=%s,p50_ms=%s,p90_ms=%s,p95_ms=%s,p99_ms=%s,max_ms=%s",
+				milliseconds(g.latency.Min), milliseconds(g.latency.Mean), milliseconds(g.latency.P50),
+				milliseconds(g.latency.P90), milliseconds(g.latency.P95), milliseconds(g.latency.P99),
+				milliseconds(g.latency.Max))
+		}
+		buf.WriteString(" " + ts + "\n")
+	}
+
+	buf.WriteString("stresstea_run")
+	writeInfluxTags(&buf, []string{"run_id", f.runID})
+	fmt.Fprintf(&buf, " iterations=%di,in_flight=%di,rps=%s", f.iterations, f.inFlight, formatValue(f.rps))
+	if f.hasTarget {
+		fmt.Fprintf(&buf, ",target_rps=%s", formatValue(f.targetRPS))
+	}
+	buf.WriteString(" " + ts + "\n")
+
+	return w.post(buf.Bytes())
+}
+
+func (w *influxWriter) post(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create influxdb request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send influxdb request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("influxdb returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (w *influxWriter) close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
+
+// influxEscaper escapes tag keys and values
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// writeInfluxTags appends ",name=value" for each pair; InfluxDB rejects
+// empty tag values, so those are skipped
+func writeInfluxTags(buf *bytes.Buffer, pairs []string) {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		buf.WriteString("," + influxEscaper.Replace(pairs[i]) + "=" + influxEscaper.Replace(pairs[i+1]))
+	}
+}
+
+// milliseconds formats a duration as fractional milliseconds
+func milliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+}
@@ -0,0 +1,468 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/config"
+	"github.com/paniccaaa/stresstea/internal/hdr"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"go.uber.org/zap"
+)
+
+// OTLP data is sent as JSON over HTTP (OTLP/HTTP with the JSON encoding), so
+// no protobuf or OpenTelemetry SDK is needed. Metrics are cumulative since
+// the start of the run.
+
+// maxPendingSpans caps the spans buffered between two pushes; further spans
+// are dropped and counted
+const maxPendingSpans = 10000
+
+// OTLP aggregation temporality and span enums
+const (
+	temporalityCumulative = 2
+	spanKindClient        = 3
+	spanStatusError       = 2
+)
+
+// OTLP pushes run metrics and the client spans of sampled requests to an
+// OpenTelemetry collector
+type OTLP struct {
+	agg      *aggregator.Aggregator
+	tester   loadtest.LoadTester
+	config   *config.OTLPConfig
+	logger   *zap.Logger
+	client   *http.Client
+	profile  *loadtest.RateProfile // nil for closed-model runs
+	resource otlpResource
+
+	mu           sync.Mutex
+	spans        []otlpSpan
+	droppedSpans int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewOTLP creates an exporter for the run aggregated by agg
+func NewOTLP(agg *aggregator.Aggregator, tester loadtest.LoadTester, runID string, cfg *config.OTLPConfig, logger *zap.Logger) *OTLP {
+	service := cfg.ServiceName
+	if service == "" {
+		service = config.DefaultOTLPConfig().ServiceName
+	}
+
+	return &OTLP{
+		agg:     agg,
+		tester:  tester,
+		config:  cfg,
+		logger:  logger,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		profile: rateProfile(agg),
+		resource: otlpResource{Attributes: attributes(
+			"service.name", service,
+			"stresstea.run_id", runID,
+			"stresstea.target", agg.Config().Test.Target,
+		)},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Start pushes every configured interval until Close
+func (o *OTLP) Start() {
+	interval := o.config.Interval
+	if interval <= 0 {
+		interval = config.DefaultOTLPConfig().Interval
+	}
+
+	go func() {
+		defer close(o.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-o.stop:
+				return
+			case <-ticker.C:
+				o.push()
+			}
+		}
+	}()
+}
+
+// Close stops the periodic push and sends the final state of the run
+func (o *OTLP) Close() error {
+	close(o.stop)
+	<-o.done
+
+	o.mu.Lock()
+	dropped := o.droppedSpans
+	o.mu.Unlock()
+	if dropped > 0 {
+		o.logger.Warn("otlp span buffer was full, spans were not exported", zap.Int64("dropped", dropped))
+	}
+
+	return errors.Join(o.pushMetrics(time.Now()), o.pushSpans())
+}
+
+// AddSpan buffers the client span of a sampled request until the next push
+func (o *OTLP) AddSpan(result loadtest.Result) {
+	trace := result.Trace
+	if trace == nil {
+		return
+	}
+
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(trace.TraceID[:]),
+		SpanID:            hex.EncodeToString(trace.SpanID[:]),
+		Name:              trace.Method,
+		Kind:              spanKindClient,
+		StartTimeUnixNano: unixNano(result.Timestamp),
+		EndTimeUnixNano:   unixNano(result.Timestamp.Add(result.Latency)),
+		Attributes: attributes(
+			"http.request.method", trace.Method,
+			"url.full", trace.URL,
+			"stresstea.scenario", result.Scenario,
+			"stresstea.step", result.Step,
+		),
+	}
+	if result.Status > 0 {
+		span.Attributes = append(span.Attributes, intAttribute("http.response.status_code", int64(result.Status)))
+	}
+	switch {
+	case result.Error != nil:
+		span.Status = otlpStatus{Code: spanStatusError, Message: result.Error.Error()}
+	case result.Status >= 400:
+		span.Status = otlpStatus{Code: spanStatusError}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.spans) >= maxPendingSpans {
+		o.droppedSpans++
+		return
+	}
+	o.spans = append(o.spans, span)
+}
+
+// push sends metrics and spans; failures are logged, the run goes on
+func (o *OTLP) push() {
+	if err := o.pushMetrics(time.Now()); err != nil {
+		o.logger.Warn("failed to export metrics", zap.Error(err))
+	}
+	if err := o.pushSpans(); err != nil {
+		o.logger.Warn("failed to export spans", zap.Error(err))
+	}
+}
+
+func (o *OTLP) pushMetrics(now time.Time) error {
+	return o.post("/v1/metrics", otlpMetricsRequest{
+		ResourceMetrics: []otlpResourceMetrics{{
+			Resource: o.resource,
+			ScopeMetrics: []otlpScopeMetrics{{
+				Scope:   otlpScope{Name: "stresstea"},
+				Metrics: o.metrics(now),
+			}},
+		}},
+	})
+}
+
+func (o *OTLP) pushSpans() error {
+	o.mu.Lock()
+	spans := o.spans
+	o.spans = nil
+	o.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	return o.post("/v1/traces", otlpTracesRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: o.resource,
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "stresstea"},
+				Spans: spans,
+			}},
+		}},
+	})
+}
+
+// metrics converts the current state of the run
+func (o *OTLP) metrics(now time.Time) []otlpMetric {
+	snap := o.agg.Snapshot()
+	stats := o.tester.Stats()
+	groups := o.agg.ByLabels()
+	start := unixNano(o.agg.Start())
+	ts := unixNano(now)
+
+	requests := make([]otlpNumberPoint, 0, len(groups))
+	failures := make([]otlpNumberPoint, 0, len(groups))
+	for _, g := range groups {
+		attrs := attributes("scenario", g.Scenario, "step", g.Step, "status", g.Status)
+		requests = append(requests, intPoint(attrs, start, ts, g.Requests))
+		if g.Errors > 0 {
+			failures = append(failures, intPoint(attrs, start, ts, g.Errors))
+		}
+	}
+
+	var durations []otlpHistogramPoint
+	for _, step := range byStep(groups) {
+		durations = append(durations, histogramPoint(attributes(step.labels...), start, ts, step.latencies))
+	}
+
+	metrics := []otlpMetric{
+		counter("stresstea.requests", "Completed requests.", "{request}", requests),
+		counter("stresstea.request.errors", "Failed requests.", "{request}", failures),
+		counter("stresstea.received", "Response bytes received.", "By", []otlpNumberPoint{intPoint(nil, start, ts, snap.Bytes)}),
+		counter("stresstea.iterations", "Completed scenario or virtual user iterations.", "{iteration}",
+			[]otlpNumberPoint{intPoint(nil, start, ts, snap.Iterations)}),
+		counter("stresstea.requests.late", "Requests that waited for a free worker.", "{request}",
+			[]otlpNumberPoint{intPoint(nil, start, ts, stats.Late)}),
+		counter("stresstea.requests.dropped", "Requests skipped because the worker pool was saturated.", "{request}",
+			[]otlpNumberPoint{intPoint(nil, start, ts, stats.Dropped)}),
+		{
+			Name:        "stresstea.request.duration",
+			Description: "Service time of successful requests.",
+			Unit:        "s",
+			Histogram:   &otlpHistogram{DataPoints: durations, AggregationTemporality: temporalityCumulative},
+		},
+		gauge("stresstea.requests.in_flight", "Requests currently running.", "{request}", float64(stats.InFlight), ts),
+		gauge("stresstea.rps", "Request rate over the last completed interval.", "{request}/s", achievedRate(o.agg, now), ts),
+	}
+	if o.profile != nil {
+		target := o.profile.At(now.Sub(o.agg.Start())).Rate
+		metrics = append(metrics, gauge("stresstea.rps.target", "Target request rate.", "{request}/s", target, ts))
+	}
+
+	return metrics
+}
+
+// post sends a JSON-encoded OTLP request to path under the endpoint
+func (o *OTLP) post(path string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode otlp request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.client.Timeout)
+	defer cancel()
+
+	url := strings.TrimSuffix(o.config.Endpoint, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create otlp request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range o.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send otlp request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp receiver %s returned %s", url, resp.Status)
+	}
+	return nil
+}
+
+func counter(name, description, unit string, points []otlpNumberPoint) otlpMetric {
+	return otlpMetric{
+		Name:        name,
+		Description: description,
+		Unit:        unit,
+		Sum: &otlpSum{
+			DataPoints:             points,
+			AggregationTemporality: temporalityCumulative,
+			IsMonotonic:            true,
+		},
+	}
+}
+
+func gauge(name, description, unit string, value float64, ts string) otlpMetric {
+	return otlpMetric{
+		Name:        name,
+		Description: description,
+		Unit:        unit,
+		Gauge:       &otlpGauge{DataPoints: []otlpNumberPoint{{TimeUnixNano: ts, AsDouble: &value}}},
+	}
+}
+
+func intPoint(attrs []otlpKeyValue, start, ts string, value int64) otlpNumberPoint {
+	return otlpNumberPoint{
+		Attributes:        attrs,
+		StartTimeUnixNano: start,
+		TimeUnixNano:      ts,
+		AsInt:             strconv.FormatInt(value, 10),
+	}
+}
+
+func histogramPoint(attrs []otlpKeyValue, start, ts string, h *hdr.Histogram) otlpHistogramPoint {
+	counts := bucketCounts(h)
+	point := otlpHistogramPoint{
+		Attributes:        attrs,
+		StartTimeUnixNano: start,
+		TimeUnixNano:      ts,
+		Count:             strconv.FormatInt(h.Count(), 10),
+		Sum:               h.Mean().Seconds() * float64(h.Count()),
+		BucketCounts:      make([]string, len(counts)),
+		ExplicitBounds:    latencyBuckets,
+	}
+	for i, count := range counts {
+		point.BucketCounts[i] = strconv.FormatInt(count, 10)
+	}
+	if h.Count() > 0 {
+		minimum, maximum := h.Min().Seconds(), h.Max().Seconds()
+		point.Min, point.Max = &minimum, &maximum
+	}
+
+	return point
+}
+
+// attributes turns key/value pairs into OTLP attributes, skipping empty values
+func attributes(pairs ...string) []otlpKeyValue {
+	var attrs []otlpKeyValue
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		attrs = append(attrs, otlpKeyValue{Key: pairs[i], Value: otlpAnyValue{StringValue: pairs[i+1]}})
+	}
+	return attrs
+}
+
+func intAttribute(key string, value int64) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: strconv.FormatInt(value, 10)}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// The types below mirror the JSON mapping of the OTLP protobuf messages.
+// 64-bit integers are strings, trace and span IDs are hex.
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberPoint `json:"dataPoints"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type otlpNumberPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsInt             string         `json:"asInt,omitempty"`
+	AsDouble          *float64       `json:"asDouble,omitempty"`
+}
+
+type otlpHistogramPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	BucketCounts      []string       `json:"bucketCounts"`
+	ExplicitBounds    []float64      `json:"explicitBounds"`
+	Min               *float64       `json:"min,omitempty"`
+	Max               *float64       `json:"max,omitempty"`
+}
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue,omitempty"`
+	IntValue    string `json:"intValue,omitempty"`
+}
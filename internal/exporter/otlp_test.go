@@ -0,0 +1,208 @@
+package exporter
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/config"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"github.com/paniccaaa/stresstea/internal/parser"
+	"go.uber.org/zap"
+)
+
+// fakeTester reports fixed scheduler statistics
+type fakeTester struct{ stats loadtest.SchedulerStats }
+
+func (f fakeTester) Run(ctx context.Context, results chan<- loadtest.Result) error { return nil }
+func (f fakeTester) Stats() loadtest.SchedulerStats                                { return f.stats }
+
+// otlpReceiver is a fake OTLP/HTTP collector recording what it receives
+type otlpReceiver struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	bodies  map[string][]byte
+	headers map[string]http.Header
+}
+
+func newOTLPReceiver(t *testing.T) *otlpReceiver {
+	t.Helper()
+
+	r := &otlpReceiver{bodies: make(map[string][]byte), headers: make(map[string]http.Header)}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.bodies[req.URL.Path] = body
+		r.headers[req.URL.Path] = req.Header.Clone()
+		r.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+func TestOTLPExport(t *testing.T) {
+	receiver := newOTLPReceiver(t)
+
+	start := time.Now().Add(-2 * time.Second)
+	agg := aggregator.New(&parser.Config{Test: &parser.TestRunConfig{
+		Target:   "http://service.local/",
+		Protocol: "http",
+		Duration: time.Minute,
+		Rate:     10,
+	}}, start)
+
+	trace := &loadtest.TraceContext{Method: "GET", URL: "http://service.local/"}
+	copy(trace.TraceID[:], []byte("0123456789abcdef"))
+	copy(trace.SpanID[:], []byte("spanid01"))
+
+	results := []loadtest.Result{
+		{Timestamp: start, Latency: 20 * time.Millisecond, Status: 200, Bytes: 100, Trace: trace},
+		{Timestamp: start, Latency: 30 * time.Millisecond, Status: 200, Bytes: 100},
+		{Timestamp: start, Latency: time.Millisecond, Error: errors.New("connection refused")},
+	}
+
+	otlp := NewOTLP(agg, fakeTester{stats: loadtest.SchedulerStats{InFlight: 3}}, "run-1", &config.OTLPConfig{
+		Endpoint:    receiver.URL,
+		Headers:     map[string]string{"Authorization": "Bearer secret"},
+		Interval:    time.Hour,
+		ServiceName: "checkout-load",
+	}, zap.NewNop())
+	otlp.Start()
+
+	for _, r := range results {
+		agg.Add(r)
+		otlp.AddSpan(r)
+	}
+
+	if err := otlp.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	for _, path := range []string{"/v1/metrics", "/v1/traces"} {
+		headers, ok := receiver.headers[path]
+		if !ok {
+			t.Fatalf("nothing was posted to %s", path)
+		}
+		if got := headers.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("%s: Authorization = %q, want the configured header", path, got)
+		}
+		if got := headers.Get("Content-Type"); got != "application/json" {
+			t.Errorf("%s: Content-Type = %q, want application/json", path, got)
+		}
+	}
+
+	t.Run("metrics", func(t *testing.T) {
+		var req otlpMetricsRequest
+		if err := json.Unmarshal(receiver.bodies["/v1/metrics"], &req); err != nil {
+			t.Fatalf("metrics payload is not OTLP JSON: %v", err)
+		}
+		if len(req.ResourceMetrics) != 1 || len(req.ResourceMetrics[0].ScopeMetrics) != 1 {
+			t.Fatalf("unexpected payload layout: %s", receiver.bodies["/v1/metrics"])
+		}
+
+		resource := attributeMap(req.ResourceMetrics[0].Resource.Attributes)
+		if resource["service.name"] != "checkout-load" || resource["stresstea.run_id"] != "run-1" {
+			t.Errorf("resource attributes = %v", resource)
+		}
+
+		metrics := make(map[string]otlpMetric)
+		for _, m := range req.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+			metrics[m.Name] = m
+		}
+
+		requests := metrics["stresstea.requests"].Sum
+		if requests == nil || !requests.IsMonotonic || requests.AggregationTemporality != temporalityCumulative {
+			t.Fatalf("stresstea.requests is not a cumulative monotonic sum: %+v", metrics["stresstea.requests"])
+		}
+		byStatus := make(map[string]string)
+		for _, p := range requests.DataPoints {
+			byStatus[attributeMap(p.Attributes)["status"]] = p.AsInt
+		}
+		if byStatus["200"] != "2" || byStatus[aggregator.StatusError] != "1" {
+			t.Errorf("requests by status = %v, want 200:2 and error:1", byStatus)
+		}
+
+		if received := metrics["stresstea.received"].Sum; received == nil || received.DataPoints[0].AsInt != "200" {
+			t.Errorf("stresstea.received = %+v, want 200 bytes", metrics["stresstea.received"])
+		}
+
+		duration := metrics["stresstea.request.duration"].Histogram
+		if duration == nil || len(duration.DataPoints) != 1 {
+			t.Fatalf("stresstea.request.duration = %+v", metrics["stresstea.request.duration"])
+		}
+		point := duration.DataPoints[0]
+		if point.Count != "2" {
+			t.Errorf("histogram count = %s, want the 2 successful requests", point.Count)
+		}
+		if len(point.BucketCounts) != len(point.ExplicitBounds)+1 {
+			t.Errorf("%d bucket counts for %d bounds", len(point.BucketCounts), len(point.ExplicitBounds))
+		}
+
+		inFlight := metrics["stresstea.requests.in_flight"].Gauge
+		if inFlight == nil || *inFlight.DataPoints[0].AsDouble != 3 {
+			t.Errorf("stresstea.requests.in_flight = %+v, want 3", metrics["stresstea.requests.in_flight"])
+		}
+	})
+
+	t.Run("spans", func(t *testing.T) {
+		var req otlpTracesRequest
+		if err := json.Unmarshal(receiver.bodies["/v1/traces"], &req); err != nil {
+			t.Fatalf("traces payload is not OTLP JSON: %v", err)
+		}
+		if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+			t.Fatalf("unexpected payload layout: %s", receiver.bodies["/v1/traces"])
+		}
+
+		spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+		if len(spans) != 1 {
+			t.Fatalf("got %d spans, want only the sampled request", len(spans))
+		}
+
+		span := spans[0]
+		if span.TraceID != hex.EncodeToString(trace.TraceID[:]) || span.SpanID != hex.EncodeToString(trace.SpanID[:]) {
+			t.Errorf("span ids %s/%s do not match the traceparent sent", span.TraceID, span.SpanID)
+		}
+		if span.Kind != spanKindClient || span.Name != "GET" {
+			t.Errorf("span kind %d name %q, want a GET client span", span.Kind, span.Name)
+		}
+		if span.Status.Code != 0 {
+			t.Errorf("span status = %+v, want unset for a 200", span.Status)
+		}
+
+		attrs := attributeMap(span.Attributes)
+		if attrs["url.full"] != "http://service.local/" || attrs["http.request.method"] != "GET" {
+			t.Errorf("span attributes = %v", attrs)
+		}
+
+		wantEnd := start.Add(20 * time.Millisecond).UnixNano()
+		if span.EndTimeUnixNano != unixNano(time.Unix(0, wantEnd)) {
+			t.Errorf("span ends at %s, want %d", span.EndTimeUnixNano, wantEnd)
+		}
+	})
+}
+
+// attributeMap flattens attributes for lookups; integers are kept as strings
+func attributeMap(attrs []otlpKeyValue) map[string]string {
+	m := make(map[string]string, len(attrs))
+	for _, a := range attrs {
+		m[a.Key] = a.Value.StringValue
+		if a.Value.IntValue != "" {
+			m[a.Key] = a.Value.IntValue
+		}
+	}
+	return m
+}
@@ -0,0 +1,205 @@
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/hdr"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"go.uber.org/zap"
+)
+
+// Prometheus serves the metrics of the run in the Prometheus text format
+type Prometheus struct {
+	agg     *aggregator.Aggregator
+	tester  loadtest.LoadTester
+	profile *loadtest.RateProfile // nil for closed-model runs
+	logger  *zap.Logger
+	server  *http.Server
+}
+
+// NewPrometheus creates an exporter for the run aggregated by agg
+func NewPrometheus(agg *aggregator.Aggregator, tester loadtest.LoadTester, logger *zap.Logger) *Prometheus {
+	return &Prometheus{agg: agg, tester: tester, profile: rateProfile(agg), logger: logger}
+}
+
+// Listen starts serving /metrics on addr. The listener is opened before
+// returning, so a busy port is reported right away.
+func (p *Prometheus) Listen(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", p)
+	p.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		// ErrServerClosed is the normal result of Close
+		if err := p.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			p.logger.Error("prometheus endpoint stopped", zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+// Close stops the listener, letting in-flight scrapes finish
+func (p *Prometheus) Close() error {
+	if p.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return p.server.Shutdown(ctx)
+}
+
+// ServeHTTP writes the current metrics
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	buf := bufio.NewWriter(w)
+	p.write(buf, time.Now())
+	buf.Flush()
+}
+
+// write renders every metric as of now
+func (p *Prometheus) write(w io.Writer, now time.Time) {
+	snap := p.agg.Snapshot()
+	stats := p.tester.Stats()
+	groups := p.agg.ByLabels()
+
+	family(w, "stresstea_requests_total", "counter", "Completed requests.")
+	for _, g := range groups {
+		sample(w, "stresstea_requests_total", labelPairs(g.Labels), float64(g.Requests))
+	}
+
+	family(w, "stresstea_request_errors_total", "counter", "Failed requests.")
+	for _, g := range groups {
+		if g.Errors > 0 {
+			sample(w, "stresstea_request_errors_total", labelPairs(g.Labels), float64(g.Errors))
+		}
+	}
+
+	family(w, "stresstea_received_bytes_total", "counter", "Response bytes received.")
+	sample(w, "stresstea_received_bytes_total", nil, float64(snap.Bytes))
+
+	family(w, "stresstea_request_duration_seconds", "histogram", "Service time of successful requests.")
+	for _, step := range byStep(groups) {
+		histogram(w, "stresstea_request_duration_seconds", step.labels, step.latencies)
+	}
+
+	family(w, "stresstea_iterations_total", "counter", "Completed scenario or virtual user iterations.")
+	sample(w, "stresstea_iterations_total", nil, float64(snap.Iterations))
+
+	family(w, "stresstea_in_flight_requests", "gauge", "Requests currently running.")
+	sample(w, "stresstea_in_flight_requests", nil, float64(stats.InFlight))
+	family(w, "stresstea_concurrency_limit", "gauge", "Maximum number of in-flight requests or virtual users.")
+	sample(w, "stresstea_concurrency_limit", nil, float64(stats.Concurrency))
+
+	family(w, "stresstea_scheduled_requests_total", "counter", "Requests scheduled by the arrival-rate executor.")
+	sample(w, "stresstea_scheduled_requests_total", nil, float64(stats.Scheduled))
+	family(w, "stresstea_late_requests_total", "counter", "Requests that waited for a free worker.")
+	sample(w, "stresstea_late_requests_total", nil, float64(stats.Late))
+	family(w, "stresstea_dropped_requests_total", "counter", "Requests skipped because the worker pool was saturated.")
+	sample(w, "stresstea_dropped_requests_total", nil, float64(stats.Dropped))
+
+	if p.profile != nil {
+		family(w, "stresstea_target_rps", "gauge", "Target request rate at this point of the run.")
+		sample(w, "stresstea_target_rps", nil, p.profile.At(now.Sub(p.agg.Start())).Rate)
+	}
+
+	family(w, "stresstea_achieved_rps", "gauge", "Request rate over the last completed interval.")
+	sample(w, "stresstea_achieved_rps", nil, achievedRate(p.agg, now))
+}
+
+// stepLatencies merges the latencies of one scenario step over all statuses
+type stepLatencies struct {
+	labels    []string
+	latencies *hdr.Histogram
+}
+
+// byStep merges label groups that only differ in status, keeping their order
+func byStep(groups []aggregator.LabeledStats) []stepLatencies {
+	var steps []stepLatencies
+	for i, g := range groups {
+		if i == 0 || g.Scenario != groups[i-1].Scenario || g.Step != groups[i-1].Step {
+			steps = append(steps, stepLatencies{
+				labels:    []string{"scenario", g.Scenario, "step", g.Step},
+				latencies: g.Latency,
+			})
+			continue
+		}
+		steps[len(steps)-1].latencies.Merge(g.Latency)
+	}
+
+	return steps
+}
+
+// labelPairs returns the name/value pairs of a label set
+func labelPairs(l aggregator.Labels) []string {
+	return []string{"scenario", l.Scenario, "step", l.Step, "status", l.Status}
+}
+
+func family(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one value; labels are name/value pairs
+func sample(w io.Writer, name string, labels []string, value float64) {
+	fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(labels), formatValue(value))
+}
+
+// histogram writes the cumulative buckets, sum and count of h
+func histogram(w io.Writer, name string, labels []string, h *hdr.Histogram) {
+	counts := bucketCounts(h)
+
+	var cumulative int64
+	for i, bound := range latencyBuckets {
+		cumulative += counts[i]
+		sample(w, name+"_bucket", append(labels[:len(labels):len(labels)], "le", formatValue(bound)), float64(cumulative))
+	}
+	sample(w, name+"_bucket", append(labels[:len(labels):len(labels)], "le", "+Inf"), float64(h.Count()))
+	sample(w, name+"_sum", labels, h.Mean().Seconds()*float64(h.Count()))
+	sample(w, name+"_count", labels, float64(h.Count()))
+}
+
+// formatLabels renders {name="value",...}, escaping values as the text
+// format requires
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(labels[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,124 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+)
+
+// maxDatagram keeps StatsD packets within a typical Ethernet MTU
+const maxDatagram = 1432
+
+// statsDWriter sends flushes to StatsD over UDP. Counters are sent as the
+// increase since the previous flush, latencies and rates as gauges. Tags use
+// the DogStatsD "|#name:value" extension understood by Datadog, Telegraf
+// and most StatsD servers.
+type statsDWriter struct {
+	conn net.Conn
+	last map[aggregator.Labels]labeledMetrics // counters at the previous flush
+}
+
+func newStatsDWriter(address string) (*statsDWriter, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open statsd connection to %s: %w", address, err)
+	}
+
+	return &statsDWriter{conn: conn, last: make(map[aggregator.Labels]labeledMetrics)}, nil
+}
+
+func (w *statsDWriter) write(f flush) error {
+	var lines []string
+
+	for _, g := range f.groups {
+		tags := statsDTags(append([]string{"run_id", f.runID}, g.tags()...))
+		prev := w.last[g.Labels]
+		w.last[g.Labels] = g
+
+		lines = appendCounter(lines, "stresstea.requests", g.requests-prev.requests, tags)
+		lines = appendCounter(lines, "stresstea.request.errors", g.errors-prev.errors, tags)
+		lines = appendCounter(lines, "stresstea.received_bytes", g.bytes-prev.bytes, tags)
+		if g.count > 0 {
+			for _, stat := range []struct {
+				name  string
+				value time.Duration
+			}{
+				{"mean", g.latency.Mean}, {"p50", g.latency.P50}, {"p90", g.latency.P90},
+				{"p95", g.latency.P95}, {"p99", g.latency.P99}, {"max", g.latency.Max},
+			} {
+				lines = append(lines, fmt.Sprintf("stresstea.latency.%s:%s|g%s", stat.name, milliseconds(stat.value), tags))
+			}
+		}
+	}
+
+	tags := statsDTags([]string{"run_id", f.runID})
+	lines = append(lines,
+		fmt.Sprintf("stresstea.iterations:%d|g%s", f.iterations, tags),
+		fmt.Sprintf("stresstea.in_flight:%d|g%s", f.inFlight, tags),
+		fmt.Sprintf("stresstea.rps:%s|g%s", formatValue(f.rps), tags),
+	)
+	if f.hasTarget {
+		lines = append(lines, fmt.Sprintf("stresstea.target_rps:%s|g%s", formatValue(f.targetRPS), tags))
+	}
+
+	return w.send(lines)
+}
+
+// send packs lines into datagrams of at most maxDatagram bytes
+func (w *statsDWriter) send(lines []string) error {
+	var packet bytes.Buffer
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxDatagram {
+			if _, err := w.conn.Write(packet.Bytes()); err != nil {
+				return fmt.Errorf("failed to send statsd packet: %w", err)
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+
+	if packet.Len() > 0 {
+		if _, err := w.conn.Write(packet.Bytes()); err != nil {
+			return fmt.Errorf("failed to send statsd packet: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *statsDWriter) close() error {
+	return w.conn.Close()
+}
+
+// appendCounter skips counters that did not change since the previous flush
+func appendCounter(lines []string, name string, delta int64, tags string) []string {
+	if delta <= 0 {
+		return lines
+	}
+	return append(lines, fmt.Sprintf("%s:%d|c%s", name, delta, tags))
+}
+
+// statsDEscaper replaces the characters that delimit StatsD tags
+var statsDEscaper = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", " ")
+
+// statsDTags formats name/value pairs as "|#name:value,...", skipping empty values
+func statsDTags(pairs []string) string {
+	var tags []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		tags = append(tags, pairs[i]+":"+statsDEscaper.Replace(pairs[i+1]))
+	}
+
+	if len(tags) == 0 {
+		return ""
+	}
+	return "|#" + strings.Join(tags, ",")
+}
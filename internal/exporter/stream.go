@@ -0,0 +1,184 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/config"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"go.uber.org/zap"
+)
+
+// streamWriter sends flushes of run metrics to one backend
+type streamWriter interface {
+	write(f flush) error
+	close() error
+}
+
+// flush is the state of the run at one point in time. Counters and latency
+// statistics are cumulative since the start of the run.
+type flush struct {
+	time   time.Time
+	runID  string
+	groups []labeledMetrics
+
+	iterations int64
+	inFlight   int64
+	rps        float64
+	targetRPS  float64 // only meaningful when hasTarget is set
+	hasTarget  bool
+}
+
+// labeledMetrics are the counters and latencies of one scenario/step/status
+type labeledMetrics struct {
+	aggregator.Labels
+	requests int64
+	errors   int64
+	bytes    int64
+	latency  aggregator.LatencyStats
+	count    int64 // requests behind latency
+}
+
+// tags returns the labels as name/value pairs
+func (m labeledMetrics) tags() []string {
+	return []string{"scenario", m.Scenario, "step", m.Step, "status", m.Status}
+}
+
+// Stream periodically flushes the aggregated metrics of the run to a
+// streaming output such as InfluxDB or StatsD
+type Stream struct {
+	agg      *aggregator.Aggregator
+	tester   loadtest.LoadTester
+	profile  *loadtest.RateProfile // nil for closed-model runs
+	runID    string
+	interval time.Duration
+	config   config.StreamConfig
+	writer   streamWriter
+	logger   *zap.Logger
+
+	mu      sync.Mutex // serializes writes of the ticker and Close
+	failing bool       // the last flush failed, further failures are not logged
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewStream creates the output described by cfg, flushing every interval
+func NewStream(cfg config.StreamConfig, agg *aggregator.Aggregator, tester loadtest.LoadTester, runID string, interval time.Duration, logger *zap.Logger) (*Stream, error) {
+	var (
+		writer streamWriter
+		err    error
+	)
+	switch cfg.Type {
+	case config.StreamInfluxDB:
+		writer, err = newInfluxWriter(cfg.Address)
+	case config.StreamStatsD:
+		writer, err = newStatsDWriter(cfg.Address)
+	default:
+		err = fmt.Errorf("unknown output %q", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	return &Stream{
+		agg:      agg,
+		tester:   tester,
+		profile:  rateProfile(agg),
+		runID:    runID,
+		interval: interval,
+		config:   cfg,
+		writer:   writer,
+		logger:   logger,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Start flushes every interval until Close
+func (s *Stream) Start() {
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.flush()
+			}
+		}
+	}()
+}
+
+// Close stops the periodic flush, sends the final state of the run and
+// releases the output
+func (s *Stream) Close() error {
+	close(s.stop)
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writer.write(s.collect(time.Now())); err != nil {
+		return errors.Join(fmt.Errorf("failed to flush metrics to %s: %w", s.config.Type, err), s.writer.close())
+	}
+	return s.writer.close()
+}
+
+// flush writes the current state. Failures are logged once until the
+// output recovers: the run goes on and the TUI stays readable.
+func (s *Stream) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.writer.write(s.collect(time.Now()))
+	switch {
+	case err != nil && !s.failing:
+		s.logger.Warn("failed to flush metrics", zap.String("output", s.config.Type), zap.Error(err))
+	case err == nil && s.failing:
+		s.logger.Info("metrics output recovered", zap.String("output", s.config.Type))
+	}
+	s.failing = err != nil
+}
+
+// collect reads the aggregator and the tester
+func (s *Stream) collect(now time.Time) flush {
+	groups := s.agg.ByLabels()
+	stats := s.tester.Stats()
+
+	f := flush{
+		time:       now,
+		runID:      s.runID,
+		groups:     make([]labeledMetrics, 0, len(groups)),
+		iterations: s.agg.Snapshot().Iterations,
+		inFlight:   stats.InFlight,
+		rps:        achievedRate(s.agg, now),
+	}
+	for _, g := range groups {
+		f.groups = append(f.groups, labeledMetrics{
+			Labels:   g.Labels,
+			requests: g.Requests,
+			errors:   g.Errors,
+			bytes:    g.Bytes,
+			latency:  aggregator.LatencyStatsOf(g.Latency),
+			count:    g.Latency.Count(),
+		})
+	}
+	if s.profile != nil {
+		f.targetRPS = s.profile.At(now.Sub(s.agg.Start())).Rate
+		f.hasTarget = true
+	}
+
+	return f
+}
@@ -0,0 +1,219 @@
+// Package hdr implements a High Dynamic Range histogram of durations.
+//
+// Values are grouped into buckets whose width grows with the value, so every
+// recorded duration is kept to a fixed number of significant decimal digits
+// while memory stays bounded no matter how many values are recorded. The
+// layout follows HdrHistogram: bucket i covers [2^i, 2^(i+1)) * subBucketHalf
+// and is split into subBucketHalf linear sub-buckets.
+package hdr
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Histogram records durations with a fixed relative precision.
+// It is not safe for concurrent use.
+type Histogram struct {
+	digits        int
+	halfCount     int64 // sub-buckets in the upper half of every bucket
+	halfMagnitude int   // log2(halfCount)
+	mask          uint64
+
+	counts []int64 // grown on demand up to the largest recorded value
+	total  int64
+	sum    float64
+	min    int64
+	max    int64
+}
+
+// New creates a histogram keeping significantDigits (1-5) decimal digits of
+// every value, e.g. 3 digits = 0.1% precision
+func New(significantDigits int) *Histogram {
+	if significantDigits < 1 {
+		significantDigits = 1
+	}
+	if significantDigits > 5 {
+		significantDigits = 5
+	}
+
+	// The smallest power of two that resolves 2 * 10^digits distinct values
+	largest := 2 * math.Pow10(significantDigits)
+	magnitude := int(math.Ceil(math.Log2(largest)))
+	count := int64(1) << magnitude
+
+	return &Histogram{
+		digits:        significantDigits,
+		halfCount:     count / 2,
+		halfMagnitude: magnitude - 1,
+		mask:          uint64(count - 1),
+		min:           math.MaxInt64,
+	}
+}
+
+// Record adds a single duration. Negative durations are recorded as 0.
+func (h *Histogram) Record(d time.Duration) {
+	h.RecordN(d, 1)
+}
+
+// RecordN adds n occurrences of a duration
+func (h *Histogram) RecordN(d time.Duration, n int64) {
+	if n <= 0 {
+		return
+	}
+
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+
+	index := h.index(v)
+	if index >= len(h.counts) {
+		h.grow(index + 1)
+	}
+
+	h.counts[index] += n
+	h.total += n
+	h.sum += float64(v) * float64(n)
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// Merge adds every value recorded in other
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other.total == 0 {
+		return
+	}
+
+	if other.digits != h.digits {
+		other.ForEach(func(value time.Duration, count int64) {
+			h.RecordN(value, count)
+		})
+		return
+	}
+
+	if len(other.counts) > len(h.counts) {
+		h.grow(len(other.counts))
+	}
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+
+	h.total += other.total
+	h.sum += other.sum
+	h.min = min(h.min, other.min)
+	h.max = max(h.max, other.max)
+}
+
+// Reset forgets every recorded value but keeps the allocated buckets
+func (h *Histogram) Reset() {
+	clear(h.counts)
+	h.total = 0
+	h.sum = 0
+	h.min = math.MaxInt64
+	h.max = 0
+}
+
+// Count returns the number of recorded values
+func (h *Histogram) Count() int64 {
+	return h.total
+}
+
+// Min returns the smallest recorded value
+func (h *Histogram) Min() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.min)
+}
+
+// Max returns the largest recorded value
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max)
+}
+
+// Mean returns the exact mean of the recorded values
+func (h *Histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / float64(h.total))
+}
+
+// Percentile returns the value below which p percent of the recorded values
+// fall, within the histogram's precision
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	p = math.Max(0, math.Min(100, p))
+	target := int64(math.Ceil(p / 100 * float64(h.total)))
+	if target < 1 {
+		target = 1
+	}
+
+	var seen int64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= target {
+			// Report the top of the bucket, but never above what was recorded
+			return time.Duration(min(h.highestEquivalent(h.valueAt(i)), h.max))
+		}
+	}
+
+	return time.Duration(h.max)
+}
+
+// ForEach calls fn for every non-empty bucket in increasing order. value is
+// the highest value that falls into the bucket.
+func (h *Histogram) ForEach(fn func(value time.Duration, count int64)) {
+	for i, count := range h.counts {
+		if count == 0 {
+			continue
+		}
+		fn(time.Duration(min(h.highestEquivalent(h.valueAt(i)), h.max)), count)
+	}
+}
+
+// index returns the position of v in counts
+func (h *Histogram) index(v int64) int {
+	bucket := h.bucketIndex(v)
+	sub := int64(uint64(v) >> uint(bucket))
+
+	// Bucket 0 uses all of its sub-buckets, the others only the upper half
+	return int((int64(bucket)+1)<<h.halfMagnitude + sub - h.halfCount)
+}
+
+// bucketIndex returns the power-of-two bucket of v
+func (h *Histogram) bucketIndex(v int64) int {
+	return 64 - bits.LeadingZeros64(uint64(v)|h.mask) - (h.halfMagnitude + 1)
+}
+
+// valueAt returns the lowest value that maps to counts[index]
+func (h *Histogram) valueAt(index int) int64 {
+	bucket := (index >> h.halfMagnitude) - 1
+	sub := int64(index)&(h.halfCount-1) + h.halfCount
+	if bucket < 0 {
+		sub -= h.halfCount
+		bucket = 0
+	}
+
+	return sub << uint(bucket)
+}
+
+// highestEquivalent returns the largest value sharing a bucket with v
+func (h *Histogram) highestEquivalent(v int64) int64 {
+	return v + int64(1)<<uint(h.bucketIndex(v)) - 1
+}
+
+func (h *Histogram) grow(size int) {
+	counts := make([]int64, size)
+	copy(counts, h.counts)
+	h.counts = counts
+}
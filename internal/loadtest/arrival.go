@@ -0,0 +1,84 @@
+package loadtest
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+)
+
+// arrivalProcess generates the offsets of successive requests. The profile
+// provides the average rate; the process decides how arrivals spread around it.
+type arrivalProcess struct {
+	profile *RateProfile
+	kind    string
+	rnd     *rand.Rand
+	cycle   time.Duration // bursty: on + off
+	on      time.Duration // bursty: burst length
+
+	count float64 // position on the cumulative arrivals axis
+}
+
+// newArrivalProcess builds the process described by cfg. A zero seed is
+// replaced with a random one and written back so the run can be reproduced.
+func newArrivalProcess(profile *RateProfile, cfg *parser.ArrivalConfig) *arrivalProcess {
+	a := &arrivalProcess{
+		profile: profile,
+		kind:    parser.ArrivalConstant,
+	}
+
+	if cfg == nil || cfg.Type == "" || cfg.Type == parser.ArrivalConstant {
+		return a
+	}
+
+	if cfg.Seed == 0 {
+		cfg.Seed = rand.Uint64()
+	}
+
+	a.kind = cfg.Type
+	a.rnd = rand.New(rand.NewPCG(cfg.Seed, cfg.Seed))
+	a.on = cfg.On
+	a.cycle = cfg.On + cfg.Off
+
+	return a
+}
+
+// next returns the offset of the next arrival from the start of the run
+func (a *arrivalProcess) next() (time.Duration, bool) {
+	var position float64
+	switch a.kind {
+	case parser.ArrivalPoisson, parser.ArrivalBursty:
+		// Exponential inter-arrival times with the profile's mean; bursty
+		// arrivals are then squeezed into the "on" part of every cycle
+		a.count += a.rnd.ExpFloat64()
+		position = a.count
+	default:
+		position = a.count
+		a.count++
+	}
+
+	offset, ok := a.profile.ArrivalTime(position)
+	if !ok {
+		return 0, false
+	}
+
+	if a.kind == parser.ArrivalBursty {
+		offset = a.burst(offset)
+	}
+
+	return offset, true
+}
+
+// burst maps an offset onto the "on" window of its cycle. Every cycle keeps
+// its number of arrivals, so the average rate is preserved while requests
+// arrive cycle/on times faster during bursts and not at all in between.
+func (a *arrivalProcess) burst(offset time.Duration) time.Duration {
+	if a.cycle <= 0 || a.cycle == a.on {
+		return offset
+	}
+
+	cycleStart := offset - offset%a.cycle
+	within := offset - cycleStart
+
+	return cycleStart + time.Duration(float64(within)*float64(a.on)/float64(a.cycle))
+}
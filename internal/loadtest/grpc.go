@@ -0,0 +1,212 @@
+package loadtest
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/health/grpc_health_v1" // registers the default health check method
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// defaultGRPCCall is used when a gRPC run does not specify a method
+var defaultGRPCCall = parser.GRPCStepConfig{
+	Service: "grpc.health.v1.Health",
+	Method:  "Check",
+}
+
+type GRPCTester struct {
+	*BaseTester
+	conn   *grpc.ClientConn
+	source descriptorSource
+
+	mu    sync.Mutex
+	calls map[*parser.GRPCStepConfig]*grpcCall
+}
+
+// grpcCall is a resolved unary method together with its prepared request
+type grpcCall struct {
+	path    string
+	output  protoreflect.MessageDescriptor
+	request proto.Message
+	stream  *grpc.StreamDesc // nil for unary methods
+}
+
+func NewGRPCTester(cfg *parser.Config) (*GRPCTester, error) {
+	address, creds := grpcDialTarget(cfg.Test.Target)
+
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create grpc client: %w", err)
+	}
+
+	source, err := newDescriptorSource(conn, cfg.Test.GRPCSchema)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to load grpc schema: %w", err)
+	}
+
+	return &GRPCTester{
+		BaseTester: NewBaseTester(cfg),
+		conn:       conn,
+		source:     source,
+		calls:      make(map[*parser.GRPCStepConfig]*grpcCall),
+	}, nil
+}
+
+func (g *GRPCTester) Run(ctx context.Context, results chan<- Result) error {
+	defer close(results)
+	defer g.Close()
+
+	step := g.config.Test.GRPC
+	if step == nil {
+		step = &defaultGRPCCall
+	}
+
+	return g.iterate(ctx, results, func(ctx context.Context, lag time.Duration) bool {
+		return g.invoke(ctx, step, func(result Result) bool {
+			return sendResult(ctx, results, result.withLag(lag))
+		})
+	})
+}
+
+// Close releases the underlying connection
+func (g *GRPCTester) Close() error {
+	return g.conn.Close()
+}
+
+// invoke executes step and passes its results to emit: one result for unary
+// calls, several for streams. It returns false once emit refuses a result.
+func (g *GRPCTester) invoke(ctx context.Context, step *parser.GRPCStepConfig, emit func(Result) bool) bool {
+	call, err := g.prepare(step)
+	if err != nil {
+		return emit(Result{
+			Timestamp: time.Now(),
+			Error:     err,
+		})
+	}
+
+	if call.stream != nil {
+		return g.runStream(ctx, call, step, emit)
+	}
+
+	return emit(g.call(ctx, call, step))
+}
+
+// call performs a single unary call. It is bounded by ctx, so stopping the
+// run also stops calls in flight.
+func (g *GRPCTester) call(ctx context.Context, call *grpcCall, step *parser.GRPCStepConfig) Result {
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if len(step.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(step.Headers))
+	}
+
+	response := dynamicpb.NewMessage(call.output)
+	err := g.conn.Invoke(ctx, call.path, call.request, response)
+	latency := time.Since(start)
+
+	code := status.Code(err)
+	if err != nil {
+		return Result{
+			Timestamp: start,
+			Latency:   latency,
+			GRPCCode:  code.String(),
+			Error:     fmt.Errorf("grpc call failed: %w", err),
+		}
+	}
+
+	return Result{
+		Timestamp: start,
+		Latency:   latency,
+		GRPCCode:  code.String(),
+		Bytes:     int64(proto.Size(response)),
+	}
+}
+
+// prepare resolves the method descriptor and encodes the request once per step
+func (g *GRPCTester) prepare(step *parser.GRPCStepConfig) (*grpcCall, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if call, ok := g.calls[step]; ok {
+		return call, nil
+	}
+
+	method, err := g.source.FindMethod(step.Service, step.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := buildMessage(method.Input(), step.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	call := &grpcCall{
+		path:    fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name()),
+		output:  method.Output(),
+		request: request,
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		call.stream = &grpc.StreamDesc{
+			StreamName:    string(method.Name()),
+			ClientStreams: method.IsStreamingClient(),
+			ServerStreams: method.IsStreamingServer(),
+		}
+	}
+	g.calls[step] = call
+
+	return call, nil
+}
+
+// buildMessage converts the YAML request map into a protobuf message
+func buildMessage(desc protoreflect.MessageDescriptor, fields map[string]interface{}) (proto.Message, error) {
+	msg := dynamicpb.NewMessage(desc)
+	if len(fields) == 0 {
+		return msg, nil
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("request does not match %s: %w", desc.FullName(), err)
+	}
+
+	return msg, nil
+}
+
+// grpcDialTarget strips the scheme from target and picks transport credentials.
+// grpcs:// and https:// targets use TLS, everything else is plaintext.
+func grpcDialTarget(target string) (string, credentials.TransportCredentials) {
+	for _, scheme := range []string{"grpcs://", "https://"} {
+		if strings.HasPrefix(target, scheme) {
+			return strings.TrimPrefix(target, scheme), credentials.NewTLS(&tls.Config{})
+		}
+	}
+
+	for _, scheme := range []string{"grpc://", "http://"} {
+		target = strings.TrimPrefix(target, scheme)
+	}
+
+	return target, insecure.NewCredentials()
+}
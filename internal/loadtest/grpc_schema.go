@@ -0,0 +1,323 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/paniccaaa/stresstea/internal/parser"
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// descriptorSource finds method descriptors for gRPC calls
+type descriptorSource interface {
+	FindMethod(service, method string) (protoreflect.MethodDescriptor, error)
+}
+
+// newDescriptorSource builds the lookup chain for cfg: schema files first,
+// then protos linked into the binary, then server reflection.
+func newDescriptorSource(conn *grpc.ClientConn, cfg *parser.GRPCSchemaConfig) (descriptorSource, error) {
+	var sources chainSource
+
+	if cfg != nil && len(cfg.Files) > 0 {
+		files, err := loadSchemaFiles(cfg.Files, cfg.ImportPaths)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, &registrySource{files: files})
+	}
+
+	sources = append(sources, &registrySource{files: protoregistry.GlobalFiles})
+
+	if cfg == nil || cfg.Reflection == nil || *cfg.Reflection {
+		sources = append(sources, newReflectionSource(conn))
+	}
+
+	return sources, nil
+}
+
+// chainSource tries every source in order and returns the first match
+type chainSource []descriptorSource
+
+func (c chainSource) FindMethod(service, method string) (protoreflect.MethodDescriptor, error) {
+	var errs []error
+	for _, source := range c {
+		desc, err := source.FindMethod(service, method)
+		if err == nil {
+			return desc, nil
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, fmt.Errorf("failed to resolve %s/%s: %w", service, method, errors.Join(errs...))
+}
+
+// registrySource resolves methods from a set of already loaded files
+type registrySource struct {
+	files *protoregistry.Files
+}
+
+func (r *registrySource) FindMethod(service, method string) (protoreflect.MethodDescriptor, error) {
+	desc, err := r.files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", service, err)
+	}
+
+	serviceDesc, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+
+	methodDesc := serviceDesc.Methods().ByName(protoreflect.Name(method))
+	if methodDesc == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", method, service)
+	}
+
+	return methodDesc, nil
+}
+
+// loadSchemaFiles loads .proto sources or binary descriptor sets from disk
+func loadSchemaFiles(paths, importPaths []string) (*protoregistry.Files, error) {
+	files := new(protoregistry.Files)
+
+	var sources []string
+	for _, path := range paths {
+		if filepath.Ext(path) == ".proto" {
+			sources = append(sources, path)
+			continue
+		}
+
+		set, err := loadDescriptorSet(path)
+		if err != nil {
+			return nil, err
+		}
+		var rangeErr error
+		set.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+			if err := files.RegisterFile(fd); err != nil {
+				rangeErr = fmt.Errorf("failed to register %s: %w", fd.Path(), err)
+				return false
+			}
+			return true
+		})
+		if rangeErr != nil {
+			return nil, rangeErr
+		}
+	}
+
+	if len(sources) == 0 {
+		return files, nil
+	}
+
+	if len(importPaths) == 0 {
+		importPaths = []string{"."}
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: importPaths}),
+	}
+
+	compiled, err := compiler.Compile(context.Background(), sources...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile proto files: %w", err)
+	}
+
+	for _, fd := range compiled {
+		if err := files.RegisterFile(fd); err != nil {
+			return nil, fmt.Errorf("failed to register %s: %w", fd.Path(), err)
+		}
+	}
+
+	return files, nil
+}
+
+// loadDescriptorSet reads a FileDescriptorSet as produced by
+// protoc --descriptor_set_out --include_imports
+func loadDescriptorSet(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set %s: %w", path, err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %s (was it built with --include_imports?): %w", path, err)
+	}
+
+	return files, nil
+}
+
+// reflectionSource fetches descriptors from the target's server reflection service
+type reflectionSource struct {
+	conn *grpc.ClientConn
+
+	mu    sync.Mutex
+	files *protoregistry.Files
+}
+
+func newReflectionSource(conn *grpc.ClientConn) *reflectionSource {
+	return &reflectionSource{
+		conn:  conn,
+		files: new(protoregistry.Files),
+	}
+}
+
+func (r *reflectionSource) FindMethod(service, method string) (protoreflect.MethodDescriptor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	registry := &registrySource{files: r.files}
+	if desc, err := registry.FindMethod(service, method); err == nil {
+		return desc, nil
+	}
+
+	if err := r.fetch(service); err != nil {
+		return nil, fmt.Errorf("server reflection: %w", err)
+	}
+
+	return registry.FindMethod(service, method)
+}
+
+// fetch downloads the file declaring symbol and all of its missing dependencies
+func (r *reflectionSource) fetch(symbol string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := rpb.NewServerReflectionClient(r.conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.CloseSend()
+
+	protos := make(map[string]*descriptorpb.FileDescriptorProto)
+
+	pending, err := r.request(stream, protos, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	})
+	if err != nil {
+		return err
+	}
+
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if _, ok := protos[name]; ok || r.known(name) {
+			continue
+		}
+
+		more, err := r.request(stream, protos, &rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+		})
+		if err != nil {
+			return err
+		}
+		pending = append(pending, more...)
+	}
+
+	for name := range protos {
+		if err := r.register(name, protos); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// request sends a single reflection request, stores the returned files and
+// returns the dependencies that still have to be fetched
+func (r *reflectionSource) request(stream rpb.ServerReflection_ServerReflectionInfoClient, protos map[string]*descriptorpb.FileDescriptorProto, req *rpb.ServerReflectionRequest) ([]string, error) {
+	if err := stream.Send(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return nil, fmt.Errorf("%s", errResp.GetErrorMessage())
+	}
+
+	var deps []string
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fdp := new(descriptorpb.FileDescriptorProto)
+		if err := proto.Unmarshal(raw, fdp); err != nil {
+			return nil, fmt.Errorf("invalid file descriptor: %w", err)
+		}
+		protos[fdp.GetName()] = fdp
+		deps = append(deps, fdp.GetDependency()...)
+	}
+
+	return deps, nil
+}
+
+// register adds the named file to the local registry after its dependencies
+func (r *reflectionSource) register(name string, protos map[string]*descriptorpb.FileDescriptorProto) error {
+	if _, err := r.files.FindFileByPath(name); err == nil {
+		return nil
+	}
+
+	fdp, ok := protos[name]
+	if !ok {
+		// Provided by the protos linked into the binary
+		return nil
+	}
+
+	for _, dep := range fdp.GetDependency() {
+		if err := r.register(dep, protos); err != nil {
+			return err
+		}
+	}
+
+	fd, err := protodesc.NewFile(fdp, reflectionResolver{r.files})
+	if err != nil {
+		return fmt.Errorf("invalid descriptor %s: %w", name, err)
+	}
+
+	return r.files.RegisterFile(fd)
+}
+
+// known reports whether a file is already available without fetching it
+func (r *reflectionSource) known(name string) bool {
+	if _, err := r.files.FindFileByPath(name); err == nil {
+		return true
+	}
+	_, err := protoregistry.GlobalFiles.FindFileByPath(name)
+	return err == nil
+}
+
+// reflectionResolver resolves dependencies from fetched files first and
+// falls back to the protos linked into the binary
+type reflectionResolver struct {
+	files *protoregistry.Files
+}
+
+func (r reflectionResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.files.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r reflectionResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if desc, err := r.files.FindDescriptorByName(name); err == nil {
+		return desc, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
@@ -0,0 +1,222 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// errEmitStopped is returned when the results consumer is gone
+var errEmitStopped = errors.New("emit stopped")
+
+// runStream drives a single client-, server- or bidi-streaming call. It emits
+// the stream setup time, one result per message and the termination status.
+func (g *GRPCTester) runStream(ctx context.Context, call *grpcCall, step *parser.GRPCStepConfig, emit func(Result) bool) bool {
+	settings := parser.GRPCStreamConfig{Messages: 1}
+	if step.Stream != nil {
+		settings = *step.Stream
+		if settings.Messages == 0 {
+			settings.Messages = 1
+		}
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	if settings.Lifetime > 0 {
+		streamCtx, cancel = context.WithTimeout(ctx, settings.Lifetime)
+	}
+	defer cancel()
+
+	if len(step.Headers) > 0 {
+		streamCtx = metadata.NewOutgoingContext(streamCtx, metadata.New(step.Headers))
+	}
+
+	start := time.Now()
+	stream, err := g.conn.NewStream(streamCtx, call.stream, call.path)
+	if err != nil {
+		return emit(streamEnd(start, 0, err, false))
+	}
+
+	if !emit(Result{
+		Kind:      KindStreamSetup,
+		Timestamp: start,
+		Latency:   time.Since(start),
+	}) {
+		return false
+	}
+
+	// received counts bytes that were not reported with a message result
+	var received int64
+	switch {
+	case call.stream.ClientStreams && call.stream.ServerStreams:
+		received, err = g.bidiStream(streamCtx, stream, call, settings, emit)
+	case call.stream.ClientStreams:
+		received, err = g.clientStream(streamCtx, stream, call, settings, emit)
+	default:
+		err = g.serverStream(stream, call, emit)
+	}
+
+	if errors.Is(err, errEmitStopped) {
+		return false
+	}
+
+	// Reaching the configured lifetime is the expected way for long-lived
+	// streams to end, so it is not reported as an error.
+	lifetimeReached := ctx.Err() == nil && errors.Is(streamCtx.Err(), context.DeadlineExceeded)
+
+	return emit(streamEnd(start, received, err, lifetimeReached))
+}
+
+// serverStream sends the request once and measures the gap between received messages
+func (g *GRPCTester) serverStream(stream grpc.ClientStream, call *grpcCall, emit func(Result) bool) error {
+	if err := stream.SendMsg(call.request); err != nil {
+		return finalStatus(stream, call, err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	last := time.Now()
+	for {
+		msg := dynamicpb.NewMessage(call.output)
+		if err := stream.RecvMsg(msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		now := time.Now()
+		if !emit(Result{
+			Kind:      KindStreamMessage,
+			Timestamp: last,
+			Latency:   now.Sub(last),
+			Bytes:     int64(proto.Size(msg)),
+		}) {
+			return errEmitStopped
+		}
+		last = now
+	}
+}
+
+// clientStream sends the configured number of messages and waits for the
+// single response, whose size it returns. Sent messages are only counted.
+func (g *GRPCTester) clientStream(ctx context.Context, stream grpc.ClientStream, call *grpcCall, settings parser.GRPCStreamConfig, emit func(Result) bool) (int64, error) {
+	for i := 0; i < settings.Messages; i++ {
+		if i > 0 && settings.Interval > 0 && !sleep(ctx, settings.Interval) {
+			break
+		}
+
+		start := time.Now()
+		if err := stream.SendMsg(call.request); err != nil {
+			return 0, finalStatus(stream, call, err)
+		}
+
+		if !emit(Result{Kind: KindStreamSend, Timestamp: start}) {
+			return 0, errEmitStopped
+		}
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return 0, err
+	}
+
+	response := dynamicpb.NewMessage(call.output)
+	if err := stream.RecvMsg(response); err != nil {
+		return 0, err
+	}
+
+	return int64(proto.Size(response)), nil
+}
+
+// bidiStream exchanges messages ping-pong style: every sent message waits for
+// one response and the round trip is recorded as the message latency. It
+// returns the size of the responses drained after the last exchange.
+func (g *GRPCTester) bidiStream(ctx context.Context, stream grpc.ClientStream, call *grpcCall, settings parser.GRPCStreamConfig, emit func(Result) bool) (int64, error) {
+	var received int64
+	for i := 0; i < settings.Messages; i++ {
+		if i > 0 && settings.Interval > 0 && !sleep(ctx, settings.Interval) {
+			break
+		}
+
+		start := time.Now()
+		if err := stream.SendMsg(call.request); err != nil {
+			return received, finalStatus(stream, call, err)
+		}
+
+		response := dynamicpb.NewMessage(call.output)
+		if err := stream.RecvMsg(response); err != nil {
+			if err == io.EOF {
+				return received, nil
+			}
+			return received, err
+		}
+
+		if !emit(Result{
+			Kind:      KindStreamMessage,
+			Timestamp: start,
+			Latency:   time.Since(start),
+			Bytes:     int64(proto.Size(response)),
+		}) {
+			return received, errEmitStopped
+		}
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return received, err
+	}
+
+	// Drain whatever is left to learn the final status
+	for {
+		response := dynamicpb.NewMessage(call.output)
+		if err := stream.RecvMsg(response); err != nil {
+			if err == io.EOF {
+				return received, nil
+			}
+			return received, err
+		}
+		received += int64(proto.Size(response))
+	}
+}
+
+// finalStatus turns the io.EOF returned by SendMsg into the real stream status
+func finalStatus(stream grpc.ClientStream, call *grpcCall, err error) error {
+	if err != io.EOF {
+		return err
+	}
+
+	if err := stream.RecvMsg(dynamicpb.NewMessage(call.output)); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// streamEnd builds the termination result of a stream
+func streamEnd(start time.Time, received int64, err error, lifetimeReached bool) Result {
+	result := Result{
+		Kind:      KindStreamEnd,
+		Timestamp: start,
+		Latency:   time.Since(start),
+		Bytes:     received,
+		GRPCCode:  codes.OK.String(),
+	}
+
+	if err != nil {
+		result.GRPCCode = status.Code(err).String()
+		if !lifetimeReached {
+			result.Error = fmt.Errorf("grpc stream failed: %w", err)
+		}
+	}
+
+	return result
+}
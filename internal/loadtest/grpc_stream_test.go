@@ -0,0 +1,142 @@
+package loadtest
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+	"google.golang.org/grpc"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/protobuf/proto"
+)
+
+// streamingServer answers client streams with the total payload size and
+// server streams with one response per requested size
+type streamingServer struct {
+	testpb.UnimplementedTestServiceServer
+}
+
+func (streamingServer) StreamingInputCall(stream testpb.TestService_StreamingInputCallServer) error {
+	var total int32
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&testpb.StreamingInputCallResponse{AggregatedPayloadSize: total})
+		}
+		if err != nil {
+			return err
+		}
+		total += int32(len(req.GetPayload().GetBody()))
+	}
+}
+
+func (streamingServer) StreamingOutputCall(req *testpb.StreamingOutputCallRequest, stream testpb.TestService_StreamingOutputCallServer) error {
+	for _, p := range req.GetResponseParameters() {
+		body := make([]byte, p.GetSize())
+		if err := stream.Send(&testpb.StreamingOutputCallResponse{Payload: &testpb.Payload{Body: body}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startStreamingServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	server := grpc.NewServer()
+	testpb.RegisterTestServiceServer(server, streamingServer{})
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String()
+}
+
+// invokeStream runs step and returns its results grouped by kind
+func invokeStream(t *testing.T, tester *GRPCTester, step *parser.GRPCStepConfig) map[ResultKind][]Result {
+	t.Helper()
+
+	results := make(map[ResultKind][]Result)
+	tester.invoke(context.Background(), step, func(r Result) bool {
+		results[r.Kind] = append(results[r.Kind], r)
+		return true
+	})
+
+	ends := results[KindStreamEnd]
+	if len(ends) != 1 {
+		t.Fatalf("got %d stream ends, want 1", len(ends))
+	}
+	if ends[0].Error != nil {
+		t.Fatalf("stream failed: %v", ends[0].Error)
+	}
+
+	return results
+}
+
+func TestGRPCClientStream(t *testing.T) {
+	tester := newTestGRPCTester(t, startStreamingServer(t))
+
+	results := invokeStream(t, tester, &parser.GRPCStepConfig{
+		Service: "grpc.testing.TestService",
+		Method:  "StreamingInputCall",
+		Request: map[string]interface{}{"payload": map[string]interface{}{"body": "AAAAAA=="}},
+		Stream:  &parser.GRPCStreamConfig{Messages: 3},
+	})
+
+	sent := results[KindStreamSend]
+	if len(sent) != 3 {
+		t.Fatalf("got %d sent messages, want 3", len(sent))
+	}
+	for _, r := range sent {
+		if r.Latency != 0 || r.Bytes != 0 {
+			t.Errorf("sent message has latency %v and %d bytes, want neither", r.Latency, r.Bytes)
+		}
+	}
+	if n := len(results[KindStreamMessage]); n != 0 {
+		t.Errorf("got %d received messages, want none: sends are not timed", n)
+	}
+
+	// The single response reports the 3 * 4 bytes sent
+	want := int64(proto.Size(&testpb.StreamingInputCallResponse{AggregatedPayloadSize: 12}))
+	if got := results[KindStreamEnd][0].Bytes; got != want {
+		t.Errorf("stream end carries %d bytes, want the %d byte response", got, want)
+	}
+}
+
+func TestGRPCServerStream(t *testing.T) {
+	tester := newTestGRPCTester(t, startStreamingServer(t))
+
+	results := invokeStream(t, tester, &parser.GRPCStepConfig{
+		Service: "grpc.testing.TestService",
+		Method:  "StreamingOutputCall",
+		Request: map[string]interface{}{"responseParameters": []interface{}{
+			map[string]interface{}{"size": 10},
+			map[string]interface{}{"size": 20},
+		}},
+	})
+
+	messages := results[KindStreamMessage]
+	if len(messages) != 2 {
+		t.Fatalf("got %d received messages, want 2", len(messages))
+	}
+
+	var received int64
+	for _, r := range messages {
+		received += r.Bytes
+	}
+	if received == 0 {
+		t.Error("received messages carry no bytes")
+	}
+
+	// Bytes already reported with the messages are not repeated
+	if got := results[KindStreamEnd][0].Bytes; got != 0 {
+		t.Errorf("stream end carries %d bytes, want 0", got)
+	}
+}
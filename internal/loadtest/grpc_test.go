@@ -0,0 +1,196 @@
+package loadtest
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testGRPCServer is an in-process health server that remembers the metadata
+// and requests of the unary calls it receives
+type testGRPCServer struct {
+	addr string
+
+	mu       sync.Mutex
+	metadata []metadata.MD
+	requests []string
+}
+
+func startGRPCServer(t *testing.T, handler grpc.UnaryHandler) *testGRPCServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	ts := &testGRPCServer{addr: listener.Addr().String()}
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ts.mu.Lock()
+		ts.metadata = append(ts.metadata, md)
+		if check, ok := req.(*healthpb.HealthCheckRequest); ok {
+			ts.requests = append(ts.requests, check.GetService())
+		}
+		ts.mu.Unlock()
+
+		if handler != nil {
+			return handler(ctx, req)
+		}
+		return next(ctx, req)
+	}))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return ts
+}
+
+func newTestGRPCTester(t *testing.T, addr string) *GRPCTester {
+	t.Helper()
+
+	tester, err := NewGRPCTester(&parser.Config{Test: &parser.TestRunConfig{
+		Target:   addr,
+		Protocol: "grpc",
+		Duration: time.Second,
+		Rate:     1,
+	}})
+	if err != nil {
+		t.Fatalf("NewGRPCTester: %v", err)
+	}
+	t.Cleanup(func() { tester.Close() })
+
+	return tester
+}
+
+// invokeOnce runs step and returns its single result
+func invokeOnce(t *testing.T, ctx context.Context, tester *GRPCTester, step *parser.GRPCStepConfig) Result {
+	t.Helper()
+
+	var results []Result
+	tester.invoke(ctx, step, func(r Result) bool {
+		results = append(results, r)
+		return true
+	})
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	return results[0]
+}
+
+func TestGRPCUnaryCall(t *testing.T) {
+	server := startGRPCServer(t, nil)
+	tester := newTestGRPCTester(t, server.addr)
+
+	result := invokeOnce(t, context.Background(), tester, &parser.GRPCStepConfig{
+		Service: "grpc.health.v1.Health",
+		Method:  "Check",
+		Request: map[string]interface{}{"service": "orders"},
+		Headers: map[string]string{"x-api-key": "secret"},
+	})
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.GRPCCode != codes.OK.String() {
+		t.Errorf("GRPCCode = %q, want OK", result.GRPCCode)
+	}
+	if result.Bytes == 0 {
+		t.Error("Bytes = 0, want the size of the response")
+	}
+	if result.Latency <= 0 || result.Timestamp.IsZero() {
+		t.Errorf("latency %v and timestamp %v are not recorded", result.Latency, result.Timestamp)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.requests) != 1 || server.requests[0] != "orders" {
+		t.Errorf("server received requests for %q, want [orders]", server.requests)
+	}
+	if got := server.metadata[0].Get("x-api-key"); len(got) != 1 || got[0] != "secret" {
+		t.Errorf("x-api-key metadata = %q, want [secret]", got)
+	}
+}
+
+func TestGRPCStatusCodes(t *testing.T) {
+	server := startGRPCServer(t, nil)
+	tester := newTestGRPCTester(t, server.addr)
+
+	// The health server answers NotFound for unknown services
+	result := invokeOnce(t, context.Background(), tester, &parser.GRPCStepConfig{
+		Service: "grpc.health.v1.Health",
+		Method:  "Check",
+		Request: map[string]interface{}{"service": "unknown"},
+	})
+
+	if result.Error == nil {
+		t.Fatal("expected an error for an unknown service")
+	}
+	if result.GRPCCode != codes.NotFound.String() {
+		t.Errorf("GRPCCode = %q, want NotFound", result.GRPCCode)
+	}
+}
+
+func TestGRPCInvalidRequest(t *testing.T) {
+	server := startGRPCServer(t, nil)
+	tester := newTestGRPCTester(t, server.addr)
+
+	result := invokeOnce(t, context.Background(), tester, &parser.GRPCStepConfig{
+		Service: "grpc.health.v1.Health",
+		Method:  "Check",
+		Request: map[string]interface{}{"no_such_field": 1},
+	})
+
+	if result.Error == nil {
+		t.Fatal("expected an error for a request that does not match the input message")
+	}
+	if result.GRPCCode != "" {
+		t.Errorf("GRPCCode = %q, want none: the call was never made", result.GRPCCode)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.requests) != 0 {
+		t.Errorf("server received %d calls, want 0", len(server.requests))
+	}
+}
+
+func TestGRPCCallStopsWithRun(t *testing.T) {
+	// The handler blocks until the client gives up
+	server := startGRPCServer(t, func(ctx context.Context, req any) (any, error) {
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	})
+	tester := newTestGRPCTester(t, server.addr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	result := invokeOnce(t, ctx, tester, &parser.GRPCStepConfig{
+		Service: "grpc.health.v1.Health",
+		Method:  "Check",
+	})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("call outlived the run by %v", elapsed)
+	}
+	if result.GRPCCode != codes.Canceled.String() {
+		t.Errorf("GRPCCode = %q, want Canceled", result.GRPCCode)
+	}
+}
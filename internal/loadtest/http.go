@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/paniccaaa/stresstea/internal/parser"
@@ -14,7 +14,8 @@ import (
 
 type HTTPTester struct {
 	*BaseTester
-	client *http.Client
+	client  *http.Client
+	sampler *sampler
 }
 
 func NewHTTPTester(cfg *parser.Config) (*HTTPTester, error) {
@@ -27,82 +28,44 @@ func NewHTTPTester(cfg *parser.Config) (*HTTPTester, error) {
 		},
 	}
 
-	return &HTTPTester{
+	tester := &HTTPTester{
 		BaseTester: NewBaseTester(cfg),
 		client:     client,
-	}, nil
+	}
+	if cfg.App != nil && cfg.App.OTLP != nil {
+		tester.sampler = newSampler(cfg.App.OTLP.SampleRate)
+	}
+
+	return tester, nil
 }
 
 func (h *HTTPTester) Run(ctx context.Context, results chan<- Result) error {
 	defer close(results)
 
-	var wg sync.WaitGroup
-	workerCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	for i := 0; i < h.config.Test.Concurrent; i++ {
-		wg.Add(1)
-		go h.worker(workerCtx, &wg, results)
-	}
-
-	timer := time.NewTimer(h.config.Test.Duration)
-	defer timer.Stop()
-
-	select {
-	case <-timer.C:
-		cancel()
-		wg.Wait()
-		return nil
-	case <-ctx.Done():
-		cancel()
-		wg.Wait()
-		return ctx.Err()
-	}
+	return h.iterate(ctx, results, func(ctx context.Context, lag time.Duration) bool {
+		return sendResult(ctx, results, h.makeRequest(ctx).withLag(lag))
+	})
 }
 
-func (h *HTTPTester) worker(ctx context.Context, wg *sync.WaitGroup, results chan<- Result) {
-	defer wg.Done()
-
-	// Более точный расчет ratePerWorker
-	ratePerWorker := float64(h.config.Test.Rate) / float64(h.config.Test.Concurrent)
-	if ratePerWorker <= 0 {
-		ratePerWorker = 1
-	}
-
-	// Используем более точный интервал
-	interval := time.Duration(float64(time.Second) / ratePerWorker)
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			result := h.makeRequest()
-			select {
-			case results <- result:
-			case <-ctx.Done():
-				return
-			}
-		}
-	}
+func (h *HTTPTester) makeRequest(ctx context.Context) Result {
+	return h.doRequest(ctx, h.config.Test.Method, h.config.Test.Target, h.config.Test.Headers, h.config.Test.Body)
 }
 
-func (h *HTTPTester) makeRequest() Result {
+// doRequest executes a single HTTP request and measures it. The request is
+// bound to ctx, so stopping the run does not wait for the client timeout.
+func (h *HTTPTester) doRequest(ctx context.Context, method, target string, headers map[string]string, payload string) Result {
 	start := time.Now()
 
-	method := h.config.Test.Method
 	if method == "" {
 		method = "GET"
 	}
 
 	var body io.Reader
-	if h.config.Test.Body != "" {
-		body = strings.NewReader(h.config.Test.Body)
+	if payload != "" {
+		body = strings.NewReader(payload)
 	}
 
-	req, err := http.NewRequest(method, h.config.Test.Target, body)
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
 	if err != nil {
 		return Result{
 			Timestamp: start,
@@ -111,16 +74,22 @@ func (h *HTTPTester) makeRequest() Result {
 		}
 	}
 
-	for k, v := range h.config.Test.Headers {
+	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
 
+	span := h.sampler.trace(req)
+
+	var trace httpTrace
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+
 	resp, err := h.client.Do(req)
 	if err != nil {
 		return Result{
 			Timestamp: start,
 			Latency:   time.Since(start),
 			Error:     fmt.Errorf("failed to execute request: %w", err),
+			Trace:     span,
 		}
 	}
 	defer resp.Body.Close()
@@ -131,13 +100,17 @@ func (h *HTTPTester) makeRequest() Result {
 			Timestamp: start,
 			Latency:   time.Since(start),
 			Error:     fmt.Errorf("failed to read response: %w", err),
+			Trace:     span,
 		}
 	}
 
+	end := time.Now()
 	return Result{
 		Timestamp: start,
-		Latency:   time.Since(start),
+		Latency:   end.Sub(start),
 		Status:    resp.StatusCode,
 		Bytes:     int64(len(bodyBytes)),
+		HTTP:      trace.timing(end),
+		Trace:     span,
 	}
 }
@@ -0,0 +1,75 @@
+package loadtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+)
+
+func newTestHTTPTester(t *testing.T, target string) *HTTPTester {
+	t.Helper()
+
+	tester, err := NewHTTPTester(&parser.Config{Test: &parser.TestRunConfig{
+		Target:     target,
+		Protocol:   "http",
+		Duration:   time.Second,
+		Rate:       1,
+		Concurrent: 1,
+	}})
+	if err != nil {
+		t.Fatalf("NewHTTPTester: %v", err)
+	}
+
+	return tester
+}
+
+func TestHTTPRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+
+	tester := newTestHTTPTester(t, server.URL)
+	result := tester.doRequest(context.Background(), "GET", server.URL, map[string]string{"X-Api-Key": "secret"}, "")
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.Status != http.StatusOK || result.Bytes != 2 {
+		t.Errorf("status %d with %d bytes, want 200 with 2", result.Status, result.Bytes)
+	}
+	if result.HTTP.TTFB <= 0 {
+		t.Error("request phases are not recorded")
+	}
+}
+
+func TestHTTPRequestStopsWithRun(t *testing.T) {
+	// The handler blocks until the client goes away
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	tester := newTestHTTPTester(t, server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	result := tester.doRequest(ctx, "GET", server.URL, nil, "")
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("request outlived the run by %v", elapsed)
+	}
+	if !cutShort(ctx, result) {
+		t.Errorf("result %v is not recognised as cut short by the stop", result.Error)
+	}
+}
@@ -0,0 +1,108 @@
+package loadtest
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// HTTPTiming breaks an HTTP request down into phases. DNS, Connect and TLS
+// are zero when the phase did not happen, e.g. on a reused connection.
+type HTTPTiming struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// TTFB is the wait from writing the request to the first response byte,
+	// i.e. the time spent by the server and the network round trip
+	TTFB time.Duration
+	// Transfer is the time spent reading the response body
+	Transfer time.Duration
+	// Reused is set when the request went over a kept-alive connection
+	Reused bool
+}
+
+// Traced reports whether the timing was measured: every traced request that
+// got a response has a non-zero TTFB
+func (t HTTPTiming) Traced() bool {
+	return t.TTFB > 0
+}
+
+// httpTrace collects the moments of an HTTP request reported by httptrace.
+// Dialing may report from several goroutines at once (Happy Eyeballs), hence
+// the mutex.
+type httpTrace struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wrote        time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+func (t *httpTrace) clientTrace() *httptrace.ClientTrace {
+	now := func(at *time.Time) {
+		t.mu.Lock()
+		*at = time.Now()
+		t.mu.Unlock()
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { now(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { now(&t.dnsDone) },
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// Keep the first attempt when several addresses are dialed
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				now(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { now(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { now(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { now(&t.wrote) },
+		GotFirstResponseByte: func() { now(&t.firstByte) },
+	}
+}
+
+// timing turns the collected moments into phase durations, end being the
+// moment the response body was read
+func (t *httpTrace) timing(end time.Time) HTTPTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timing := HTTPTiming{
+		DNS:     between(t.dnsStart, t.dnsDone),
+		Connect: between(t.connectStart, t.connectDone),
+		TLS:     between(t.tlsStart, t.tlsDone),
+		TTFB:    between(t.wrote, t.firstByte),
+		Reused:  t.reused,
+	}
+	if !t.firstByte.IsZero() {
+		timing.Transfer = between(t.firstByte, end)
+	}
+
+	return timing
+}
+
+// between returns the time from start to end, 0 unless both happened
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
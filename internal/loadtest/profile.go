@@ -0,0 +1,194 @@
+package loadtest
+
+import (
+	"math"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+)
+
+// RateProfile describes the target request rate over the course of a run.
+// A run without stages is a single constant segment.
+type RateProfile struct {
+	segments []rateSegment
+	duration time.Duration
+	staged   bool
+}
+
+// rateSegment is a stretch of the run where the rate changes linearly
+type rateSegment struct {
+	start    time.Duration
+	duration time.Duration
+	from     float64
+	to       float64
+	arrivals float64 // requests scheduled before the segment starts
+}
+
+// StageInfo describes the stage active at some point of the run
+type StageInfo struct {
+	Index  int     // zero-based stage index
+	Total  int     // number of stages, 0 for constant-rate runs
+	Rate   float64 // current target rate
+	Target int     // rate at the end of the stage
+}
+
+func NewRateProfile(cfg *parser.TestRunConfig) *RateProfile {
+	p := &RateProfile{staged: len(cfg.Stages) > 0}
+
+	if len(cfg.Stages) == 0 {
+		p.add(cfg.Duration, float64(cfg.Rate), float64(cfg.Rate))
+		return p
+	}
+
+	previous := 0.0
+	for _, stage := range cfg.Stages {
+		target := float64(stage.Target)
+		if stage.Transition == parser.TransitionStep {
+			p.add(stage.Duration, target, target)
+		} else {
+			p.add(stage.Duration, previous, target)
+		}
+		previous = target
+	}
+
+	return p
+}
+
+func (p *RateProfile) add(duration time.Duration, from, to float64) {
+	arrivals := 0.0
+	if n := len(p.segments); n > 0 {
+		last := p.segments[n-1]
+		arrivals = last.arrivals + last.count(last.duration)
+	}
+
+	p.segments = append(p.segments, rateSegment{
+		start:    p.duration,
+		duration: duration,
+		from:     from,
+		to:       to,
+		arrivals: arrivals,
+	})
+	p.duration += duration
+}
+
+// Duration returns the total length of the run
+func (p *RateProfile) Duration() time.Duration {
+	return p.duration
+}
+
+// Average returns the mean target rate over the whole run
+func (p *RateProfile) Average() float64 {
+	if p.duration <= 0 {
+		return 0
+	}
+
+	last := p.segments[len(p.segments)-1]
+	return (last.arrivals + last.count(last.duration)) / p.duration.Seconds()
+}
+
+// Stages returns the number of configured stages, 0 for constant-rate runs
+func (p *RateProfile) Stages() int {
+	if !p.staged {
+		return 0
+	}
+	return len(p.segments)
+}
+
+// Boundaries returns the offsets at which stages after the first one start
+func (p *RateProfile) Boundaries() []time.Duration {
+	if !p.staged {
+		return nil
+	}
+
+	bounds := make([]time.Duration, 0, len(p.segments)-1)
+	for _, seg := range p.segments[1:] {
+		bounds = append(bounds, seg.start)
+	}
+
+	return bounds
+}
+
+// At returns the stage active after elapsed time and its current target rate
+func (p *RateProfile) At(elapsed time.Duration) StageInfo {
+	for i, seg := range p.segments {
+		if elapsed < seg.start+seg.duration || i == len(p.segments)-1 {
+			offset := elapsed - seg.start
+			if offset < 0 {
+				offset = 0
+			}
+			if offset > seg.duration {
+				offset = seg.duration
+			}
+
+			return StageInfo{
+				Index:  i,
+				Total:  p.Stages(),
+				Rate:   seg.rate(offset),
+				Target: int(math.Round(seg.to)),
+			}
+		}
+	}
+
+	return StageInfo{}
+}
+
+// ArrivalTime returns the offset at which the cumulative number of scheduled
+// requests reaches n. It returns false when n falls beyond the end of the run.
+func (p *RateProfile) ArrivalTime(n float64) (time.Duration, bool) {
+	for _, seg := range p.segments {
+		remaining := n - seg.arrivals
+		if remaining > seg.count(seg.duration) {
+			continue
+		}
+
+		return seg.start + seg.solve(remaining), true
+	}
+
+	return 0, false
+}
+
+// rate returns the target rate offset into the segment
+func (s rateSegment) rate(offset time.Duration) float64 {
+	if s.duration == 0 {
+		return s.to
+	}
+	return s.from + (s.to-s.from)*offset.Seconds()/s.duration.Seconds()
+}
+
+// count returns the number of requests scheduled in the first offset of the segment
+func (s rateSegment) count(offset time.Duration) float64 {
+	t := offset.Seconds()
+	return s.from*t + s.slope()*t*t/2
+}
+
+// solve inverts count: it returns the offset where count reaches n
+func (s rateSegment) solve(n float64) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+
+	a := s.slope() / 2
+	b := s.from
+
+	var t float64
+	if math.Abs(a) < 1e-12 {
+		t = n / b
+	} else {
+		t = (-b + math.Sqrt(b*b+4*a*n)) / (2 * a)
+	}
+
+	offset := time.Duration(t * float64(time.Second))
+	if offset > s.duration {
+		offset = s.duration
+	}
+
+	return offset
+}
+
+// slope returns the rate change per second
+func (s rateSegment) slope() float64 {
+	if s.duration == 0 {
+		return 0
+	}
+	return (s.to - s.from) / s.duration.Seconds()
+}
@@ -0,0 +1,168 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+)
+
+// ScenarioTester runs the multi-step flows from the YAML scenarios section.
+// Every executor iteration walks one flow, so rate is iterations per second;
+// scenarios are picked round-robin.
+type ScenarioTester struct {
+	*BaseTester
+	http *HTTPTester
+	grpc *GRPCTester
+	base *url.URL
+
+	iterations atomic.Int64
+}
+
+func NewScenarioTester(cfg *parser.Config) (*ScenarioTester, error) {
+	if len(cfg.Test.Scenarios) == 0 {
+		return nil, fmt.Errorf("no scenarios configured")
+	}
+
+	tester := &ScenarioTester{
+		BaseTester: NewBaseTester(cfg),
+	}
+
+	var err error
+	if hasStep(cfg.Test.Scenarios, func(step parser.StepConfig) bool { return step.HTTP != nil }) {
+		tester.base, err = url.Parse(cfg.Test.Target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target: %w", err)
+		}
+
+		tester.http, err = NewHTTPTester(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if hasStep(cfg.Test.Scenarios, func(step parser.StepConfig) bool { return step.GRPC != nil }) {
+		tester.grpc, err = NewGRPCTester(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tester, nil
+}
+
+// hasStep reports whether any scenario contains a step matching fn
+func hasStep(scenarios []parser.ScenarioConfig, fn func(parser.StepConfig) bool) bool {
+	for _, scenario := range scenarios {
+		for _, step := range scenario.Flow {
+			if fn(step) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *ScenarioTester) Run(ctx context.Context, results chan<- Result) error {
+	defer close(results)
+	if s.grpc != nil {
+		defer s.grpc.Close()
+	}
+
+	scenarios := s.config.Test.Scenarios
+
+	return s.iterate(ctx, results, func(ctx context.Context, lag time.Duration) bool {
+		n := s.iterations.Add(1) - 1
+		return s.runFlow(ctx, &scenarios[n%int64(len(scenarios))], lag, results)
+	})
+}
+
+// runFlow executes every step of the scenario once. lag is how late the
+// iteration started. It delays only the first request step: the following
+// ones start when their predecessor finishes and are on time.
+// It returns false when the context was cancelled mid-flow.
+func (s *ScenarioTester) runFlow(ctx context.Context, scenario *parser.ScenarioConfig, lag time.Duration, results chan<- Result) bool {
+	for _, step := range scenario.Flow {
+		if step.Wait != nil {
+			if !sleep(ctx, step.Wait.Duration) {
+				return false
+			}
+			continue
+		}
+
+		name := step.StepName()
+		stepLag := lag
+		emit := func(result Result) bool {
+			result.Scenario = scenario.Name
+			result.Step = name
+			return sendResult(ctx, results, result.withLag(stepLag))
+		}
+
+		if !s.runStep(ctx, &step, emit) {
+			return false
+		}
+		lag = 0
+	}
+
+	return true
+}
+
+// runStep executes a single request step and emits its results
+func (s *ScenarioTester) runStep(ctx context.Context, step *parser.StepConfig, emit func(Result) bool) bool {
+	switch {
+	case step.HTTP != nil:
+		target, err := s.resolveURL(step.HTTP.URL)
+		if err != nil {
+			return emit(Result{Timestamp: time.Now(), Error: err})
+		}
+		return emit(s.http.doRequest(ctx, step.HTTP.Method, target, s.mergeHeaders(step.HTTP.Headers), step.HTTP.Body))
+	case step.GRPC != nil:
+		return s.grpc.invoke(ctx, step.GRPC, emit)
+	default:
+		return emit(Result{Timestamp: time.Now(), Error: fmt.Errorf("empty step")})
+	}
+}
+
+// resolveURL resolves a possibly relative step URL against the global target
+func (s *ScenarioTester) resolveURL(raw string) (string, error) {
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid step url %q: %w", raw, err)
+	}
+
+	return s.base.ResolveReference(ref).String(), nil
+}
+
+// mergeHeaders overlays step headers on top of the global ones
+func (s *ScenarioTester) mergeHeaders(step map[string]string) map[string]string {
+	if len(s.config.Test.Headers) == 0 {
+		return step
+	}
+
+	headers := make(map[string]string, len(s.config.Test.Headers)+len(step))
+	for k, v := range s.config.Test.Headers {
+		headers[k] = v
+	}
+	for k, v := range step {
+		headers[k] = v
+	}
+
+	return headers
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
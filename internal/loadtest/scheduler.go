@@ -0,0 +1,166 @@
+package loadtest
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+)
+
+// lateTolerance is how long after its intended time a token may be picked up
+// without counting as late; it absorbs timer and goroutine wake-up jitter
+const lateTolerance = time.Millisecond
+
+// SchedulerStats are the counters maintained by the arrival scheduler
+type SchedulerStats struct {
+	Scheduled   int64 `json:"scheduled"`   // tokens emitted at the configured rate
+	Late        int64 `json:"late"`        // tokens a worker picked up after their intended time
+	Dropped     int64 `json:"dropped"`     // tokens discarded because the queue was full or the run stopped
+	InFlight    int64 `json:"in_flight"`   // requests currently being executed
+	Concurrency int   `json:"concurrency"` // cap on in-flight requests
+}
+
+// Scheduler is an open-model arrival scheduler. It emits request tokens at
+// the rate dictated by the profile regardless of how fast the target responds
+// and hands them to a pool of at most concurrency workers.
+type Scheduler struct {
+	profile     *RateProfile
+	arrivals    *arrivalProcess
+	concurrency int
+
+	scheduled atomic.Int64
+	late      atomic.Int64
+	dropped   atomic.Int64
+	inFlight  atomic.Int64
+}
+
+func NewScheduler(profile *RateProfile, arrival *parser.ArrivalConfig, concurrency int) *Scheduler {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	return &Scheduler{
+		profile:     profile,
+		arrivals:    newArrivalProcess(profile, arrival),
+		concurrency: concurrency,
+	}
+}
+
+// Run emits tokens until the duration elapses or ctx is cancelled and runs fn
+// for each of them. fn receives the intended start time of its token and
+// returns false to stop its worker. Run waits for running workers to exit;
+// tokens nobody picked up by then are counted as dropped.
+func (s *Scheduler) Run(ctx context.Context, fn func(ctx context.Context, intended time.Time) bool) error {
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// A queue as deep as the pool absorbs short bursts; anything beyond is dropped
+	tokens := make(chan time.Time, s.concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.concurrency; i++ {
+		wg.Add(1)
+		go s.worker(workerCtx, &wg, tokens, fn)
+	}
+
+	err := s.emit(ctx, tokens)
+
+	cancel()
+	wg.Wait()
+
+	for {
+		select {
+		case <-tokens:
+			s.dropped.Add(1)
+		default:
+			return err
+		}
+	}
+}
+
+// emit produces tokens following the rate profile and arrival distribution.
+// Intended times are derived from the start time, so a slow iteration never
+// shifts the schedule.
+func (s *Scheduler) emit(ctx context.Context, tokens chan<- time.Time) error {
+	start := time.Now()
+	deadline := start.Add(s.profile.Duration())
+
+	timer := time.NewTimer(0)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		offset, ok := s.arrivals.next()
+		if !ok {
+			break
+		}
+
+		intended := start.Add(offset)
+		if !intended.Before(deadline) {
+			break
+		}
+
+		timer.Reset(time.Until(intended))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		s.scheduled.Add(1)
+		select {
+		case tokens <- intended:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+
+	// Wait out the remainder of the last interval
+	timer.Reset(time.Until(deadline))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (s *Scheduler) worker(ctx context.Context, wg *sync.WaitGroup, tokens <-chan time.Time, fn func(context.Context, time.Time) bool) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case intended := <-tokens:
+			// Both cases may be ready after a stop; the token is not run then
+			if ctx.Err() != nil {
+				s.dropped.Add(1)
+				return
+			}
+			if time.Since(intended) > lateTolerance {
+				s.late.Add(1)
+			}
+
+			s.inFlight.Add(1)
+			ok := fn(ctx, intended)
+			s.inFlight.Add(-1)
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// Stats returns a snapshot of the scheduler counters
+func (s *Scheduler) Stats() SchedulerStats {
+	return SchedulerStats{
+		Scheduled:   s.scheduled.Load(),
+		Late:        s.late.Load(),
+		Dropped:     s.dropped.Load(),
+		InFlight:    s.inFlight.Load(),
+		Concurrency: s.concurrency,
+	}
+}
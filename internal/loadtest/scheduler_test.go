@@ -0,0 +1,60 @@
+package loadtest
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+)
+
+func TestSchedulerAccountsForEveryToken(t *testing.T) {
+	// A single slow worker cannot keep up with 200 tokens/s
+	profile := NewRateProfile(&parser.TestRunConfig{Duration: 500 * time.Millisecond, Rate: 200})
+	scheduler := NewScheduler(profile, nil, 1)
+
+	var started atomic.Int64
+	err := scheduler.Run(context.Background(), func(ctx context.Context, intended time.Time) bool {
+		started.Add(1)
+		sleep(ctx, 20*time.Millisecond)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	stats := scheduler.Stats()
+	if stats.Scheduled == 0 {
+		t.Fatal("no tokens were scheduled")
+	}
+	if got := started.Load() + stats.Dropped; got != stats.Scheduled {
+		t.Errorf("%d started + %d dropped != %d scheduled", started.Load(), stats.Dropped, stats.Scheduled)
+	}
+	if stats.Late == 0 {
+		t.Error("tokens that waited for the busy worker are not counted as late")
+	}
+	if stats.InFlight != 0 {
+		t.Errorf("%d tokens still in flight after Run", stats.InFlight)
+	}
+}
+
+func TestSchedulerDropsQueuedTokensOnStop(t *testing.T) {
+	profile := NewRateProfile(&parser.TestRunConfig{Duration: time.Minute, Rate: 500})
+	scheduler := NewScheduler(profile, nil, 4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	var started atomic.Int64
+	scheduler.Run(ctx, func(ctx context.Context, intended time.Time) bool {
+		started.Add(1)
+		<-ctx.Done()
+		return true
+	})
+
+	stats := scheduler.Stats()
+	if got := started.Load() + stats.Dropped; got != stats.Scheduled {
+		t.Errorf("%d started + %d dropped != %d scheduled", started.Load(), stats.Dropped, stats.Scheduled)
+	}
+}
@@ -0,0 +1,64 @@
+package loadtest
+
+import (
+	"encoding/hex"
+	"math/rand/v2"
+	"net/http"
+)
+
+// TraceContext identifies the client span of a sampled request. The span is
+// propagated to the target with a W3C traceparent header.
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Method  string
+	URL     string
+}
+
+// TraceParent returns the W3C traceparent header value of a sampled span
+func (t *TraceContext) TraceParent() string {
+	return "00-" + hex.EncodeToString(t.TraceID[:]) + "-" + hex.EncodeToString(t.SpanID[:]) + "-01"
+}
+
+// sampler picks the requests that are traced
+type sampler struct {
+	rate float64
+}
+
+// newSampler creates a sampler from the OTLP configuration, nil when tracing
+// is off
+func newSampler(rate float64) *sampler {
+	if rate <= 0 {
+		return nil
+	}
+	return &sampler{rate: min(rate, 1)}
+}
+
+// trace starts a span for req when it is sampled and injects its context.
+// It returns nil for requests that are not traced.
+func (s *sampler) trace(req *http.Request) *TraceContext {
+	if s == nil || (s.rate < 1 && rand.Float64() >= s.rate) {
+		return nil
+	}
+
+	t := &TraceContext{Method: req.Method, URL: req.URL.String()}
+	fillRandom(t.TraceID[:])
+	fillRandom(t.SpanID[:])
+	req.Header.Set("traceparent", t.TraceParent())
+
+	return t
+}
+
+// fillRandom fills b with random bytes, never all zeros (an invalid ID)
+func fillRandom(b []byte) {
+	for {
+		for i := range b {
+			b[i] = byte(rand.Uint32())
+		}
+		for _, v := range b {
+			if v != 0 {
+				return
+			}
+		}
+	}
+}
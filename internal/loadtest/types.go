@@ -2,29 +2,143 @@ package loadtest
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/paniccaaa/stresstea/internal/parser"
+	"google.golang.org/grpc/codes"
+)
+
+// ResultKind tells what a Result measures
+type ResultKind int
+
+const (
+	// KindRequest is a complete HTTP request or unary gRPC call
+	KindRequest ResultKind = iota
+	// KindStreamSetup measures how long it took to open a gRPC stream
+	KindStreamSetup
+	// KindStreamMessage measures a single message received on a stream
+	KindStreamMessage
+	// KindStreamEnd reports stream termination: Latency is the stream
+	// lifetime, GRPCCode the final status and Bytes what was received
+	// without a KindStreamMessage of its own
+	KindStreamEnd
+	// KindIteration covers one full iteration: a single request or a whole
+	// scenario flow. Reported for scenarios and the vus executor.
+	KindIteration
+	// KindStreamSend counts a message sent on a client stream. It has no
+	// latency: SendMsg returns as soon as the message is buffered locally.
+	KindStreamSend
 )
 
 type Result struct {
-	Timestamp time.Time
-	Latency   time.Duration
+	Kind      ResultKind
+	Timestamp time.Time     // actual start
+	Latency   time.Duration // service time, measured from the actual start
 	Error     error
 	Status    int
 	Bytes     int64
+
+	// GRPCCode is the gRPC status code name (e.g. "OK", "Unavailable").
+	// It is empty for HTTP results.
+	GRPCCode string
+
+	// Intended is when the work should have started according to the
+	// schedule. It precedes Timestamp when the worker pool was saturated.
+	Intended time.Time
+	// ResponseTime is measured from Intended to completion, so unlike Latency
+	// it includes the time spent waiting for a free worker.
+	ResponseTime time.Duration
+
+	// Scenario and Step identify the flow step that produced the result.
+	// Both are empty for plain single-request runs.
+	Scenario string
+	Step     string
+
+	// HTTP breaks HTTP requests down into phases. It is zero for gRPC.
+	HTTP HTTPTiming
+
+	// Trace is set for requests sampled for OpenTelemetry tracing
+	Trace *TraceContext
 }
 
 type LoadTester interface {
 	Run(ctx context.Context, results chan<- Result) error
+	Stats() SchedulerStats
 }
 
 type BaseTester struct {
-	config *parser.Config
+	config   *parser.Config
+	executor executor
+
+	// reportIterations enables KindIteration results
+	reportIterations bool
 }
 
 func NewBaseTester(cfg *parser.Config) *BaseTester {
 	return &BaseTester{
-		config: cfg,
+		config:           cfg,
+		executor:         newExecutor(cfg.Test),
+		reportIterations: cfg.Test.Executor == parser.ExecutorVUs || len(cfg.Test.Scenarios) > 0,
+	}
+}
+
+// Stats returns the executor counters of the running test
+func (b *BaseTester) Stats() SchedulerStats {
+	return b.executor.Stats()
+}
+
+// iterate drives iteration through the executor. iteration receives how late
+// it started relative to its schedule and returns false to stop.
+func (b *BaseTester) iterate(ctx context.Context, results chan<- Result, iteration func(ctx context.Context, lag time.Duration) bool) error {
+	return b.executor.Run(ctx, func(ctx context.Context, intended time.Time) bool {
+		start := time.Now()
+		lag := start.Sub(intended)
+
+		if !iteration(ctx, lag) {
+			return false
+		}
+
+		if !b.reportIterations {
+			return true
+		}
+
+		return sendResult(ctx, results, Result{
+			Kind:      KindIteration,
+			Timestamp: start,
+			Latency:   time.Since(start),
+		}.withLag(lag))
+	})
+}
+
+// withLag records how far behind schedule the work producing r started
+func (r Result) withLag(lag time.Duration) Result {
+	r.Intended = r.Timestamp.Add(-lag)
+	r.ResponseTime = r.Latency + lag
+	return r
+}
+
+// sendResult delivers a finished result and reports whether the run goes on.
+// Completed work is always delivered, even after ctx is done, so stopping
+// the run never loses results; the engine drains the channel until it is
+// closed. Only results of work cut short by the stop itself are dropped.
+func sendResult(ctx context.Context, results chan<- Result, result Result) bool {
+	if cutShort(ctx, result) {
+		return false
+	}
+
+	results <- result
+	return ctx.Err() == nil
+}
+
+// cutShort reports whether result failed only because the run was stopped
+func cutShort(ctx context.Context, result Result) bool {
+	if ctx.Err() == nil || result.Error == nil {
+		return false
 	}
+
+	return errors.Is(result.Error, context.Canceled) ||
+		errors.Is(result.Error, context.DeadlineExceeded) ||
+		result.GRPCCode == codes.Canceled.String() ||
+		result.GRPCCode == codes.DeadlineExceeded.String()
 }
@@ -0,0 +1,107 @@
+package loadtest
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+)
+
+// executor decides when iterations start and runs them
+type executor interface {
+	Run(ctx context.Context, fn func(ctx context.Context, intended time.Time) bool) error
+	Stats() SchedulerStats
+}
+
+// newExecutor picks the executor matching the configured load model
+func newExecutor(cfg *parser.TestRunConfig) executor {
+	if cfg.Executor == parser.ExecutorVUs {
+		return NewVUExecutor(cfg.Concurrent, cfg.Duration, cfg.ThinkTime)
+	}
+
+	return NewScheduler(NewRateProfile(cfg), cfg.Arrival, cfg.Concurrent)
+}
+
+// VUExecutor is a closed-model executor: a fixed number of virtual users loop
+// over the iteration as fast as responses allow, pausing for the think time
+// between iterations.
+type VUExecutor struct {
+	vus       int
+	duration  time.Duration
+	thinkTime parser.ThinkTimeConfig
+
+	iterations atomic.Int64
+	busy       atomic.Int64
+}
+
+func NewVUExecutor(vus int, duration time.Duration, thinkTime *parser.ThinkTimeConfig) *VUExecutor {
+	if vus <= 0 {
+		vus = 1
+	}
+
+	e := &VUExecutor{
+		vus:      vus,
+		duration: duration,
+	}
+	if thinkTime != nil {
+		e.thinkTime = *thinkTime
+	}
+
+	return e
+}
+
+// Run starts the virtual users and waits for them to stop. Iterations have
+// no schedule, so their intended start is the moment they actually start.
+func (e *VUExecutor) Run(ctx context.Context, fn func(ctx context.Context, intended time.Time) bool) error {
+	vuCtx, cancel := context.WithTimeout(ctx, e.duration)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < e.vus; i++ {
+		wg.Add(1)
+		go e.virtualUser(vuCtx, &wg, fn)
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+func (e *VUExecutor) virtualUser(ctx context.Context, wg *sync.WaitGroup, fn func(context.Context, time.Time) bool) {
+	defer wg.Done()
+
+	for ctx.Err() == nil {
+		e.iterations.Add(1)
+		e.busy.Add(1)
+		ok := fn(ctx, time.Now())
+		e.busy.Add(-1)
+		if !ok {
+			return
+		}
+
+		if think := e.think(); think > 0 && !sleep(ctx, think) {
+			return
+		}
+	}
+}
+
+// think picks a random pause from the think time range
+func (e *VUExecutor) think() time.Duration {
+	spread := e.thinkTime.Max - e.thinkTime.Min
+	if spread <= 0 {
+		return e.thinkTime.Min
+	}
+
+	return e.thinkTime.Min + rand.N(spread+1)
+}
+
+// Stats reports iterations as scheduled work and busy users as in-flight
+func (e *VUExecutor) Stats() SchedulerStats {
+	return SchedulerStats{
+		Scheduled:   e.iterations.Load(),
+		InFlight:    e.busy.Load(),
+		Concurrency: e.vus,
+	}
+}
@@ -21,6 +21,31 @@ type TestRunConfig struct {
 	Body       string            `yaml:"body,omitempty"`
 	Method     string            `yaml:"method,omitempty"`
 	CPUs       int               `yaml:"cpus,omitempty"` // Количество процессоров для использования
+
+	// Stages override Rate and Duration with a multi-stage load profile
+	Stages []StageConfig `yaml:"stages,omitempty"`
+
+	// Arrival selects the distribution of request inter-arrival times
+	Arrival *ArrivalConfig `yaml:"arrival,omitempty"`
+
+	// Executor selects the load model: arrival-rate (open, default) or
+	// vus (closed, Concurrent users looping with ThinkTime between iterations)
+	Executor  string           `yaml:"executor,omitempty"`
+	ThinkTime *ThinkTimeConfig `yaml:"think_time,omitempty"`
+
+	// GRPC describes the unary call made by gRPC runs without scenarios.
+	// Defaults to the standard health check when nil.
+	GRPC *GRPCStepConfig `yaml:"grpc,omitempty"`
+
+	// GRPCSchema tells the gRPC tester where to find message descriptors
+	GRPCSchema *GRPCSchemaConfig `yaml:"grpc_schema,omitempty"`
+
+	// Scenarios are multi-step flows walked by every virtual user.
+	// When empty, the single request described above is used.
+	Scenarios []ScenarioConfig `yaml:"scenarios,omitempty"`
+
+	// Thresholds are pass/fail checks evaluated against the final metrics
+	Thresholds []ThresholdConfig `yaml:"thresholds,omitempty"`
 }
 
 // Config is the main configuration struct that combines all configs
@@ -30,8 +55,9 @@ type Config struct {
 }
 
 type YAMLConfig struct {
-	Global    GlobalConfig     `yaml:"global"`
-	Scenarios []ScenarioConfig `yaml:"scenarios"`
+	Global     GlobalConfig      `yaml:"global"`
+	Scenarios  []ScenarioConfig  `yaml:"scenarios"`
+	Thresholds []ThresholdConfig `yaml:"thresholds,omitempty"`
 }
 
 type GlobalConfig struct {
@@ -41,6 +67,75 @@ type GlobalConfig struct {
 	Concurrent int           `yaml:"concurrent"`
 	Protocol   string        `yaml:"protocol"`
 	CPUs       int           `yaml:"cpus,omitempty"` // Количество процессоров для использования
+
+	Stages     []StageConfig     `yaml:"stages,omitempty"`
+	Arrival    *ArrivalConfig    `yaml:"arrival,omitempty"`
+	Executor   string            `yaml:"executor,omitempty"`
+	ThinkTime  *ThinkTimeConfig  `yaml:"think_time,omitempty"`
+	GRPC       *GRPCStepConfig   `yaml:"grpc,omitempty"`
+	GRPCSchema *GRPCSchemaConfig `yaml:"grpc_schema,omitempty"`
+}
+
+// StageConfig is one step of a load profile. The rate moves from the
+// previous stage's target (0 for the first stage) to Target.
+type StageConfig struct {
+	Duration   time.Duration `yaml:"duration"`
+	Target     int           `yaml:"target"`               // requests per second at the end of the stage
+	Transition string        `yaml:"transition,omitempty"` // linear (default) or step
+}
+
+const (
+	TransitionLinear = "linear"
+	TransitionStep   = "step"
+)
+
+// ArrivalConfig describes how requests are spread in time. The average rate
+// always follows the configured rate or stages.
+type ArrivalConfig struct {
+	Type string        `yaml:"type"`           // constant (default), poisson or bursty
+	Seed uint64        `yaml:"seed,omitempty"` // random seed, 0 = pick one and report it
+	On   time.Duration `yaml:"on,omitempty"`   // bursty: length of a burst
+	Off  time.Duration `yaml:"off,omitempty"`  // bursty: pause between bursts
+}
+
+const (
+	ExecutorArrivalRate = "arrival-rate"
+	ExecutorVUs         = "vus"
+)
+
+// ThinkTimeConfig is the pause a virtual user takes between iterations,
+// picked uniformly from [Min, Max]
+type ThinkTimeConfig struct {
+	Min time.Duration `yaml:"min"`
+	Max time.Duration `yaml:"max"`
+}
+
+const (
+	ArrivalConstant = "constant"
+	ArrivalPoisson  = "poisson"
+	ArrivalBursty   = "bursty"
+)
+
+// ThresholdConfig is a single pass/fail check such as "p95 < 300ms".
+// In YAML it can be written as a plain string or as a mapping.
+type ThresholdConfig struct {
+	Check string `yaml:"check"`
+
+	// AbortOnFail evaluates the check during the run and stops the run as
+	// soon as it fails; Grace delays the first evaluation after the start
+	AbortOnFail bool          `yaml:"abort_on_fail,omitempty"`
+	Grace       time.Duration `yaml:"grace,omitempty"`
+}
+
+// UnmarshalYAML accepts both "p95 < 300ms" and {check: "p95 < 300ms"}
+func (t *ThresholdConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		t.Check = node.Value
+		return nil
+	}
+
+	type plain ThresholdConfig
+	return node.Decode((*plain)(t))
 }
 
 type ScenarioConfig struct {
@@ -49,6 +144,7 @@ type ScenarioConfig struct {
 }
 
 type StepConfig struct {
+	Name string          `yaml:"name,omitempty"`
 	HTTP *HTTPStepConfig `yaml:"http,omitempty"`
 	GRPC *GRPCStepConfig `yaml:"grpc,omitempty"`
 	Wait *WaitStepConfig `yaml:"wait,omitempty"`
@@ -66,6 +162,25 @@ type GRPCStepConfig struct {
 	Method  string                 `yaml:"method"`
 	Request map[string]interface{} `yaml:"request,omitempty"`
 	Headers map[string]string      `yaml:"headers,omitempty"`
+
+	// Stream configures client-, server- and bidi-streaming methods.
+	// The streaming mode itself is taken from the method descriptor.
+	Stream *GRPCStreamConfig `yaml:"stream,omitempty"`
+}
+
+// GRPCStreamConfig describes the load pattern of a single stream
+type GRPCStreamConfig struct {
+	Messages int           `yaml:"messages,omitempty"` // messages sent per stream (client and bidi streaming)
+	Interval time.Duration `yaml:"interval,omitempty"` // pause between sent messages
+	Lifetime time.Duration `yaml:"lifetime,omitempty"` // stream is closed after this long, 0 = no limit
+}
+
+// GRPCSchemaConfig describes how gRPC method descriptors are resolved.
+// Files are tried first, then protos compiled into stresstea, then server reflection.
+type GRPCSchemaConfig struct {
+	Reflection  *bool    `yaml:"reflection,omitempty"`   // server reflection, enabled by default
+	Files       []string `yaml:"files,omitempty"`        // .proto sources or descriptor sets
+	ImportPaths []string `yaml:"import_paths,omitempty"` // import paths for .proto sources
 }
 
 type WaitStepConfig struct {
@@ -88,6 +203,11 @@ func LoadFromFile(filename string) (*Config, error) {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	// Stages define the rate and the total duration of the run
+	if len(yamlConfig.Global.Stages) > 0 {
+		yamlConfig.Global.Duration, yamlConfig.Global.Rate = stagesSummary(yamlConfig.Global.Stages)
+	}
+
 	// Convert YAML configuration to Config
 	config := &Config{
 		App: config.DefaultAppConfig(),
@@ -98,12 +218,17 @@ func LoadFromFile(filename string) (*Config, error) {
 			Concurrent: yamlConfig.Global.Concurrent,
 			Protocol:   yamlConfig.Global.Protocol,
 			CPUs:       yamlConfig.Global.CPUs,
+			Stages:     yamlConfig.Global.Stages,
+			Arrival:    yamlConfig.Global.Arrival,
+			Executor:   yamlConfig.Global.Executor,
+			ThinkTime:  yamlConfig.Global.ThinkTime,
+			GRPC:       yamlConfig.Global.GRPC,
+			GRPCSchema: yamlConfig.Global.GRPCSchema,
+			Scenarios:  yamlConfig.Scenarios,
+			Thresholds: yamlConfig.Thresholds,
 		},
 	}
 
-	// TODO: Добавить поддержку scenarios в будущем
-	// Пока используем только Global конфигурацию
-
 	return config, nil
 }
 
@@ -113,25 +238,220 @@ func validateYAMLConfig(config *YAMLConfig) error {
 		return fmt.Errorf("target is required")
 	}
 
-	if config.Global.Rate <= 0 {
-		return fmt.Errorf("rate must be positive")
+	if err := ValidateExecutor(config.Global.Executor, config.Global.ThinkTime); err != nil {
+		return err
+	}
+
+	closedModel := config.Global.Executor == ExecutorVUs
+	if closedModel && len(config.Global.Stages) > 0 {
+		return fmt.Errorf("stages are not supported with the '%s' executor", ExecutorVUs)
+	}
+
+	if len(config.Global.Stages) > 0 {
+		if err := validateStages(config.Global.Stages); err != nil {
+			return err
+		}
+	} else {
+		if config.Global.Rate <= 0 && !closedModel {
+			return fmt.Errorf("rate must be positive")
+		}
+
+		if config.Global.Duration <= 0 {
+			return fmt.Errorf("duration must be positive")
+		}
 	}
 
 	if config.Global.Concurrent <= 0 {
 		return fmt.Errorf("concurrent must be positive")
 	}
 
-	if config.Global.Duration <= 0 {
-		return fmt.Errorf("duration must be positive")
+	if err := ValidateArrival(config.Global.Arrival); err != nil {
+		return err
 	}
 
 	if config.Global.Protocol != "http" && config.Global.Protocol != "grpc" {
 		return fmt.Errorf("protocol must be 'http' or 'grpc'")
 	}
 
+	if grpc := config.Global.GRPC; grpc != nil {
+		if grpc.Service == "" || grpc.Method == "" {
+			return fmt.Errorf("grpc service and method are required")
+		}
+		if err := validateStream(grpc.Stream); err != nil {
+			return err
+		}
+	}
+
+	for i, scenario := range config.Scenarios {
+		if err := validateScenario(&scenario); err != nil {
+			return fmt.Errorf("scenario %d: %w", i+1, err)
+		}
+	}
+
+	for i, threshold := range config.Thresholds {
+		if threshold.Check == "" {
+			return fmt.Errorf("threshold %d: check is required", i+1)
+		}
+		if threshold.Grace < 0 {
+			return fmt.Errorf("threshold %d: grace must not be negative", i+1)
+		}
+	}
+
+	return nil
+}
+
+// validateScenario проверяет шаги сценария
+func validateScenario(scenario *ScenarioConfig) error {
+	if scenario.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if len(scenario.Flow) == 0 {
+		return fmt.Errorf("flow must contain at least one step")
+	}
+
+	for i, step := range scenario.Flow {
+		kinds := 0
+		if step.HTTP != nil {
+			kinds++
+			if step.HTTP.URL == "" {
+				return fmt.Errorf("step %d: http url is required", i+1)
+			}
+		}
+		if step.GRPC != nil {
+			kinds++
+			if step.GRPC.Service == "" || step.GRPC.Method == "" {
+				return fmt.Errorf("step %d: grpc service and method are required", i+1)
+			}
+			if err := validateStream(step.GRPC.Stream); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+		}
+		if step.Wait != nil {
+			kinds++
+			if step.Wait.Duration <= 0 {
+				return fmt.Errorf("step %d: wait duration must be positive", i+1)
+			}
+		}
+		if kinds != 1 {
+			return fmt.Errorf("step %d: exactly one of http, grpc or wait must be set", i+1)
+		}
+	}
+
+	return nil
+}
+
+// validateStages проверяет профиль нагрузки
+func validateStages(stages []StageConfig) error {
+	peak := 0
+	for i, stage := range stages {
+		if stage.Duration <= 0 {
+			return fmt.Errorf("stage %d: duration must be positive", i+1)
+		}
+		if stage.Target < 0 {
+			return fmt.Errorf("stage %d: target must not be negative", i+1)
+		}
+		if stage.Transition != "" && stage.Transition != TransitionLinear && stage.Transition != TransitionStep {
+			return fmt.Errorf("stage %d: transition must be '%s' or '%s'", i+1, TransitionLinear, TransitionStep)
+		}
+		if stage.Target > peak {
+			peak = stage.Target
+		}
+	}
+
+	if peak == 0 {
+		return fmt.Errorf("at least one stage must have a positive target")
+	}
+
 	return nil
 }
 
+// stagesSummary возвращает общую длительность и пиковый rate профиля
+func stagesSummary(stages []StageConfig) (time.Duration, int) {
+	var total time.Duration
+	peak := 0
+	for _, stage := range stages {
+		total += stage.Duration
+		if stage.Target > peak {
+			peak = stage.Target
+		}
+	}
+
+	return total, peak
+}
+
+// ValidateExecutor проверяет модель нагрузки и think time
+func ValidateExecutor(executor string, thinkTime *ThinkTimeConfig) error {
+	if executor != "" && executor != ExecutorArrivalRate && executor != ExecutorVUs {
+		return fmt.Errorf("executor must be '%s' or '%s'", ExecutorArrivalRate, ExecutorVUs)
+	}
+
+	if thinkTime != nil {
+		if thinkTime.Min < 0 || thinkTime.Max < thinkTime.Min {
+			return fmt.Errorf("think_time must satisfy 0 <= min <= max")
+		}
+	}
+
+	return nil
+}
+
+// ValidateArrival проверяет настройки распределения запросов
+func ValidateArrival(arrival *ArrivalConfig) error {
+	if arrival == nil {
+		return nil
+	}
+
+	switch arrival.Type {
+	case "", ArrivalConstant, ArrivalPoisson:
+	case ArrivalBursty:
+		if arrival.On <= 0 || arrival.Off < 0 {
+			return fmt.Errorf("bursty arrival requires a positive 'on' and a non-negative 'off' duration")
+		}
+	default:
+		return fmt.Errorf("arrival type must be '%s', '%s' or '%s'", ArrivalConstant, ArrivalPoisson, ArrivalBursty)
+	}
+
+	return nil
+}
+
+// validateStream проверяет параметры стрима
+func validateStream(stream *GRPCStreamConfig) error {
+	if stream == nil {
+		return nil
+	}
+
+	if stream.Messages < 0 || stream.Interval < 0 || stream.Lifetime < 0 {
+		return fmt.Errorf("stream messages, interval and lifetime must not be negative")
+	}
+
+	return nil
+}
+
+// StepName возвращает имя шага, используемое в метриках
+func (s StepConfig) StepName() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.HTTP != nil:
+		method := s.HTTP.Method
+		if method == "" {
+			method = "GET"
+		}
+		return method + " " + s.HTTP.URL
+	case s.GRPC != nil:
+		return s.GRPC.Service + "/" + s.GRPC.Method
+	case s.Wait != nil:
+		return "wait " + s.Wait.Duration.String()
+	default:
+		return "unknown"
+	}
+}
+
+// Headless reports whether the run should skip the TUI
+func (c *Config) Headless() bool {
+	return c.App != nil && c.App.TUI != nil && c.App.TUI.Headless
+}
+
 func (c *Config) SetupRuntime() {
 	if c.Test.CPUs > 0 {
 		runtime.GOMAXPROCS(c.Test.CPUs)
@@ -0,0 +1,383 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+)
+
+// The binary format is:
+//
+//	magic | uvarint version | uvarint len | JSON header | records...
+//
+// Every record starts with a tag byte. A result record holds varints:
+// kind, start offset from the header's start time, latency, schedule lag + 1
+// (0 when the result has no intended start), response time, status and
+// bytes, followed by string references for the error, gRPC code, scenario
+// and step, and since version 2 the HTTP phases: a uvarint of flags
+// (httpTraced, httpReused), then for traced requests the DNS, connect, TLS,
+// TTFB and transfer durations. A string reference is 0 for "", an index
+// into the strings seen so far, or the next index followed by the
+// length-prefixed string itself. Since version 3 the table holds at most
+// maxStrings strings; once it is full, the next index introduces a string
+// that is used inline and not added to the table.
+// The trailer record is a length-prefixed JSON document.
+
+// binaryMagic identifies binary results files
+var binaryMagic = []byte("\x89STRTEA\n")
+
+const (
+	tagResult  byte = 1
+	tagTrailer byte = 2
+)
+
+// maxStrings caps the string table, so error messages embedding unique data
+// (addresses, request IDs) cannot grow it for the whole run
+const maxStrings = 4096
+
+// Flags of the HTTP phases of a result record
+const (
+	httpTraced uint64 = 1 << iota
+	httpReused
+)
+
+type binaryEncoder struct {
+	w       *bufio.Writer
+	start   time.Time
+	strings map[string]uint64
+	scratch []byte
+}
+
+func newBinaryEncoder(w *bufio.Writer, start time.Time) *binaryEncoder {
+	return &binaryEncoder{
+		w:       w,
+		start:   start,
+		strings: make(map[string]uint64),
+		scratch: make([]byte, 0, 128),
+	}
+}
+
+func (e *binaryEncoder) header(h Header) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	buf := append([]byte{}, binaryMagic...)
+	buf = binary.AppendUvarint(buf, Version)
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	buf = append(buf, data...)
+
+	_, err = e.w.Write(buf)
+	return err
+}
+
+func (e *binaryEncoder) result(r loadtest.Result) error {
+	buf := append(e.scratch[:0], tagResult)
+	buf = binary.AppendUvarint(buf, uint64(r.Kind))
+	buf = binary.AppendVarint(buf, int64(r.Timestamp.Sub(e.start)))
+	buf = binary.AppendVarint(buf, int64(r.Latency))
+
+	var lag uint64
+	if !r.Intended.IsZero() {
+		if d := r.Timestamp.Sub(r.Intended); d > 0 {
+			lag = uint64(d)
+		}
+		lag++
+	}
+	buf = binary.AppendUvarint(buf, lag)
+	buf = binary.AppendVarint(buf, int64(r.ResponseTime))
+	buf = binary.AppendUvarint(buf, uint64(r.Status))
+	buf = binary.AppendVarint(buf, r.Bytes)
+
+	errMsg := ""
+	if r.Error != nil {
+		errMsg = r.Error.Error()
+	}
+	buf = e.appendString(buf, errMsg)
+	buf = e.appendString(buf, r.GRPCCode)
+	buf = e.appendString(buf, r.Scenario)
+	buf = e.appendString(buf, r.Step)
+	buf = appendHTTPTiming(buf, r.HTTP)
+
+	e.scratch = buf
+	_, err := e.w.Write(buf)
+	return err
+}
+
+// appendHTTPTiming writes the phases of a traced HTTP request
+func appendHTTPTiming(buf []byte, t loadtest.HTTPTiming) []byte {
+	if !t.Traced() {
+		return binary.AppendUvarint(buf, 0)
+	}
+
+	flags := httpTraced
+	if t.Reused {
+		flags |= httpReused
+	}
+	buf = binary.AppendUvarint(buf, flags)
+	for _, d := range []time.Duration{t.DNS, t.Connect, t.TLS, t.TTFB, t.Transfer} {
+		buf = binary.AppendVarint(buf, int64(d))
+	}
+
+	return buf
+}
+
+// appendString writes a reference to s, defining it on first use while the
+// table has room and writing it inline afterwards
+func (e *binaryEncoder) appendString(buf []byte, s string) []byte {
+	if s == "" {
+		return binary.AppendUvarint(buf, 0)
+	}
+	if id, ok := e.strings[s]; ok {
+		return binary.AppendUvarint(buf, id)
+	}
+
+	id := uint64(len(e.strings) + 1)
+	if len(e.strings) < maxStrings {
+		e.strings[s] = id
+	}
+	buf = binary.AppendUvarint(buf, id)
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func (e *binaryEncoder) trailer(t Trailer) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	buf := []byte{tagTrailer}
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	buf = append(buf, data...)
+
+	_, err = e.w.Write(buf)
+	return err
+}
+
+type binaryDecoder struct {
+	r       *bufio.Reader
+	version uint64
+	start   time.Time
+	strings []string
+	errors  []error // errors created for the strings, reused across results
+	end     *Trailer
+}
+
+func newBinaryDecoder(r *bufio.Reader) *binaryDecoder {
+	return &binaryDecoder{r: r}
+}
+
+func (d *binaryDecoder) header() (Header, error) {
+	magic := make([]byte, len(binaryMagic))
+	if _, err := io.ReadFull(d.r, magic); err != nil {
+		return Header{}, err
+	}
+
+	version, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return Header{}, err
+	}
+	if version > Version {
+		return Header{Version: int(version)}, nil
+	}
+	d.version = version
+
+	data, err := d.readBytes()
+	if err != nil {
+		return Header{}, err
+	}
+
+	var h Header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return Header{}, err
+	}
+	d.start = h.Start
+
+	return h, nil
+}
+
+func (d *binaryDecoder) next() (loadtest.Result, error) {
+	if d.end != nil {
+		return loadtest.Result{}, io.EOF
+	}
+
+	tag, err := d.r.ReadByte()
+	if err != nil {
+		return loadtest.Result{}, err
+	}
+
+	switch tag {
+	case tagResult:
+		return d.result()
+	case tagTrailer:
+		data, err := d.readBytes()
+		if err != nil {
+			return loadtest.Result{}, err
+		}
+		var t Trailer
+		if err := json.Unmarshal(data, &t); err != nil {
+			return loadtest.Result{}, err
+		}
+		d.end = &t
+		return loadtest.Result{}, io.EOF
+	default:
+		return loadtest.Result{}, fmt.Errorf("unknown record tag %d", tag)
+	}
+}
+
+func (d *binaryDecoder) result() (loadtest.Result, error) {
+	var fields [7]int64
+	for i := range fields {
+		var err error
+		// Fields 0, 3 and 5 (kind, lag, status) are unsigned
+		if i == 0 || i == 3 || i == 5 {
+			var v uint64
+			v, err = binary.ReadUvarint(d.r)
+			fields[i] = int64(v)
+		} else {
+			fields[i], err = binary.ReadVarint(d.r)
+		}
+		if err != nil {
+			return loadtest.Result{}, unexpected(err)
+		}
+	}
+
+	r := loadtest.Result{
+		Kind:         loadtest.ResultKind(fields[0]),
+		Timestamp:    d.start.Add(time.Duration(fields[1])),
+		Latency:      time.Duration(fields[2]),
+		ResponseTime: time.Duration(fields[4]),
+		Status:       int(fields[5]),
+		Bytes:        fields[6],
+	}
+	if lag := fields[3]; lag > 0 {
+		r.Intended = r.Timestamp.Add(-time.Duration(lag - 1))
+	}
+
+	errID, errMsg, err := d.readString()
+	if err != nil {
+		return loadtest.Result{}, err
+	}
+	switch {
+	case errID > 0:
+		r.Error = d.errors[errID-1]
+	case errMsg != "":
+		r.Error = errors.New(errMsg)
+	}
+
+	refs := []*string{&r.GRPCCode, &r.Scenario, &r.Step}
+	for _, ref := range refs {
+		_, s, err := d.readString()
+		if err != nil {
+			return loadtest.Result{}, err
+		}
+		*ref = s
+	}
+
+	if d.version >= 2 {
+		timing, err := d.readHTTPTiming()
+		if err != nil {
+			return loadtest.Result{}, err
+		}
+		r.HTTP = timing
+	}
+
+	return r, nil
+}
+
+// readHTTPTiming reads the phases of an HTTP request
+func (d *binaryDecoder) readHTTPTiming() (loadtest.HTTPTiming, error) {
+	flags, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return loadtest.HTTPTiming{}, unexpected(err)
+	}
+	if flags&httpTraced == 0 {
+		return loadtest.HTTPTiming{}, nil
+	}
+
+	var phases [5]time.Duration
+	for i := range phases {
+		v, err := binary.ReadVarint(d.r)
+		if err != nil {
+			return loadtest.HTTPTiming{}, unexpected(err)
+		}
+		phases[i] = time.Duration(v)
+	}
+
+	return loadtest.HTTPTiming{
+		DNS:      phases[0],
+		Connect:  phases[1],
+		TLS:      phases[2],
+		TTFB:     phases[3],
+		Transfer: phases[4],
+		Reused:   flags&httpReused != 0,
+	}, nil
+}
+
+// readString reads a string reference. It returns the string and its index
+// + 1 in the table, 0 for "" and for strings written inline.
+func (d *binaryDecoder) readString() (int, string, error) {
+	id, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return 0, "", unexpected(err)
+	}
+
+	switch {
+	case id == 0:
+		return 0, "", nil
+	case id <= uint64(len(d.strings)):
+		return int(id), d.strings[id-1], nil
+	case id == uint64(len(d.strings))+1:
+		data, err := d.readBytes()
+		if err != nil {
+			return 0, "", err
+		}
+		s := string(data)
+		if d.version >= 3 && len(d.strings) >= maxStrings {
+			return 0, s, nil
+		}
+		d.strings = append(d.strings, s)
+		d.errors = append(d.errors, errors.New(s))
+		return int(id), s, nil
+	default:
+		return 0, "", fmt.Errorf("invalid string reference %d", id)
+	}
+}
+
+// readBytes reads a length-prefixed byte string
+func (d *binaryDecoder) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return nil, unexpected(err)
+	}
+	if n > 64<<20 {
+		return nil, fmt.Errorf("record too large: %d bytes", n)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return nil, unexpected(err)
+	}
+
+	return data, nil
+}
+
+func (d *binaryDecoder) trailer() *Trailer {
+	return d.end
+}
+
+// unexpected reports a record cut short by the end of the file
+func unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+)
+
+func TestBinaryStringTableIsCapped(t *testing.T) {
+	start := time.Now()
+
+	// Every error is unique, as with messages embedding a local port
+	var results []loadtest.Result
+	for i := 0; i < maxStrings+100; i++ {
+		results = append(results, loadtest.Result{
+			Timestamp: start.Add(time.Duration(i)),
+			Latency:   time.Millisecond,
+			Error:     fmt.Errorf("dial tcp 127.0.0.1:%d: connection refused", 10000+i),
+			Scenario:  "checkout",
+			Step:      "GET /cart",
+		})
+	}
+
+	var file bytes.Buffer
+	w := bufio.NewWriter(&file)
+	enc := newBinaryEncoder(w, start)
+	if err := enc.header(Header{Version: Version, Start: start}); err != nil {
+		t.Fatalf("header: %v", err)
+	}
+	for _, r := range results {
+		if err := enc.result(r); err != nil {
+			t.Fatalf("result: %v", err)
+		}
+	}
+	if err := enc.trailer(Trailer{End: start.Add(time.Second)}); err != nil {
+		t.Fatalf("trailer: %v", err)
+	}
+	w.Flush()
+
+	if len(enc.strings) != maxStrings {
+		t.Errorf("encoder interned %d strings, want the cap of %d", len(enc.strings), maxStrings)
+	}
+
+	dec := newBinaryDecoder(bufio.NewReader(&file))
+	if _, err := dec.header(); err != nil {
+		t.Fatalf("read header: %v", err)
+	}
+	for i, want := range results {
+		got, err := dec.next()
+		if err != nil {
+			t.Fatalf("result %d: %v", i, err)
+		}
+		if got.Error == nil || got.Error.Error() != want.Error.Error() {
+			t.Fatalf("result %d: error %v, want %v", i, got.Error, want.Error)
+		}
+		if got.Scenario != want.Scenario || got.Step != want.Step {
+			t.Fatalf("result %d: scenario %q step %q", i, got.Scenario, got.Step)
+		}
+	}
+	if _, err := dec.next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the trailer, got %v", err)
+	}
+	if len(dec.strings) != maxStrings {
+		t.Errorf("decoder kept %d strings, want the cap of %d", len(dec.strings), maxStrings)
+	}
+}
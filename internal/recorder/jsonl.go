@@ -0,0 +1,160 @@
+package recorder
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+)
+
+// The JSON lines format is a header line, one line per result and a
+// {"end": ...} trailer line. It is larger than the binary format but easy
+// to process with jq and friends.
+
+// record is a single result as stored in JSON lines
+type record struct {
+	Kind         loadtest.ResultKind `json:"kind,omitempty"`
+	Timestamp    time.Time           `json:"ts"`
+	Latency      time.Duration       `json:"latency"`
+	Error        string              `json:"error,omitempty"`
+	Status       int                 `json:"status,omitempty"`
+	Bytes        int64               `json:"bytes,omitempty"`
+	GRPCCode     string              `json:"grpc_code,omitempty"`
+	Intended     time.Time           `json:"intended"`
+	ResponseTime time.Duration       `json:"response_time"`
+	Scenario     string              `json:"scenario,omitempty"`
+	Step         string              `json:"step,omitempty"`
+	HTTP         *httpRecord         `json:"http,omitempty"`
+
+	// End is only set on the last line of the file
+	End *Trailer `json:"end,omitempty"`
+}
+
+// httpRecord holds the phases of a traced HTTP request
+type httpRecord struct {
+	DNS      time.Duration `json:"dns,omitempty"`
+	Connect  time.Duration `json:"connect,omitempty"`
+	TLS      time.Duration `json:"tls,omitempty"`
+	TTFB     time.Duration `json:"ttfb"`
+	Transfer time.Duration `json:"transfer"`
+	Reused   bool          `json:"reused,omitempty"`
+}
+
+type jsonlEncoder struct {
+	enc *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	return &jsonlEncoder{enc: json.NewEncoder(w)}
+}
+
+func (e *jsonlEncoder) header(h Header) error {
+	return e.enc.Encode(h)
+}
+
+func (e *jsonlEncoder) result(r loadtest.Result) error {
+	rec := record{
+		Kind:         r.Kind,
+		Timestamp:    r.Timestamp,
+		Latency:      r.Latency,
+		Status:       r.Status,
+		Bytes:        r.Bytes,
+		GRPCCode:     r.GRPCCode,
+		Intended:     r.Intended,
+		ResponseTime: r.ResponseTime,
+		Scenario:     r.Scenario,
+		Step:         r.Step,
+	}
+	if r.Error != nil {
+		rec.Error = r.Error.Error()
+	}
+	if t := r.HTTP; t.Traced() {
+		rec.HTTP = &httpRecord{
+			DNS:      t.DNS,
+			Connect:  t.Connect,
+			TLS:      t.TLS,
+			TTFB:     t.TTFB,
+			Transfer: t.Transfer,
+			Reused:   t.Reused,
+		}
+	}
+
+	return e.enc.Encode(rec)
+}
+
+func (e *jsonlEncoder) trailer(t Trailer) error {
+	return e.enc.Encode(struct {
+		End *Trailer `json:"end"`
+	}{End: &t})
+}
+
+type jsonlDecoder struct {
+	dec *json.Decoder
+	end *Trailer
+}
+
+func newJSONLDecoder(r io.Reader) *jsonlDecoder {
+	return &jsonlDecoder{dec: json.NewDecoder(r)}
+}
+
+func (d *jsonlDecoder) header() (Header, error) {
+	var h Header
+	if err := d.dec.Decode(&h); err != nil {
+		return Header{}, err
+	}
+	if h.Format == "" {
+		h.Format = FormatJSONL
+	}
+
+	return h, nil
+}
+
+func (d *jsonlDecoder) next() (loadtest.Result, error) {
+	if d.end != nil {
+		return loadtest.Result{}, io.EOF
+	}
+
+	var rec record
+	if err := d.dec.Decode(&rec); err != nil {
+		return loadtest.Result{}, err
+	}
+
+	if rec.End != nil {
+		d.end = rec.End
+		return loadtest.Result{}, io.EOF
+	}
+
+	r := loadtest.Result{
+		Kind:         rec.Kind,
+		Timestamp:    rec.Timestamp,
+		Latency:      rec.Latency,
+		Status:       rec.Status,
+		Bytes:        rec.Bytes,
+		GRPCCode:     rec.GRPCCode,
+		Intended:     rec.Intended,
+		ResponseTime: rec.ResponseTime,
+		Scenario:     rec.Scenario,
+		Step:         rec.Step,
+	}
+	if rec.Error != "" {
+		r.Error = errors.New(rec.Error)
+	}
+	if t := rec.HTTP; t != nil {
+		r.HTTP = loadtest.HTTPTiming{
+			DNS:      t.DNS,
+			Connect:  t.Connect,
+			TLS:      t.TLS,
+			TTFB:     t.TTFB,
+			Transfer: t.Transfer,
+			Reused:   t.Reused,
+		}
+	}
+
+	return r, nil
+}
+
+func (d *jsonlDecoder) trailer() *Trailer {
+	return d.end
+}
@@ -0,0 +1,268 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"github.com/paniccaaa/stresstea/internal/parser"
+)
+
+// Version is the version of the results file layout written by this build.
+// Version 2 added HTTP request phases, version 3 capped the binary string table.
+const Version = 3
+
+const (
+	FormatBinary = "binary"
+	FormatJSONL  = "jsonl"
+)
+
+// queueSize is the number of results buffered for the writer goroutine
+const queueSize = 64 * 1024
+
+// Header opens a results file and describes the run that produced it
+type Header struct {
+	Version int            `json:"version"`
+	Format  string         `json:"format"`
+	Start   time.Time      `json:"start"`
+	Config  *parser.Config `json:"config"`
+}
+
+// Trailer closes a results file with the final state of the run
+type Trailer struct {
+	End       time.Time               `json:"end"`
+	Scheduler loadtest.SchedulerStats `json:"scheduler"`
+	Aborted   string                  `json:"aborted,omitempty"`
+	Dropped   int64                   `json:"dropped,omitempty"` // results lost because the writer fell behind
+}
+
+// encoder writes one of the file formats
+type encoder interface {
+	header(h Header) error
+	result(r loadtest.Result) error
+	trailer(t Trailer) error
+}
+
+// decoder reads one of the file formats. next returns io.EOF at the end of
+// the results; the trailer, if any, is returned by trailer afterwards.
+type decoder interface {
+	header() (Header, error)
+	next() (loadtest.Result, error)
+	trailer() *Trailer
+}
+
+// FormatFor picks the file format from the file extension: .jsonl, .ndjson
+// and .json are JSON lines, anything else is the compact binary format
+func FormatFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson", ".json":
+		return FormatJSONL
+	default:
+		return FormatBinary
+	}
+}
+
+// Recorder streams every result of a run to a file. Results are queued and
+// written by a dedicated goroutine, so recording never blocks the caller;
+// if the writer falls behind, results are dropped and counted in the trailer.
+type Recorder struct {
+	file  *os.File
+	buf   *bufio.Writer
+	enc   encoder
+	queue chan loadtest.Result
+	done  chan struct{}
+
+	dropped atomic.Int64
+	mu      sync.Mutex
+	err     error
+}
+
+// Create opens path for writing in the format matching its extension and
+// records the header
+func Create(path string, header Header) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create results file: %w", err)
+	}
+
+	header.Version = Version
+	header.Format = FormatFor(path)
+
+	buf := bufio.NewWriterSize(file, 256*1024)
+	r := &Recorder{
+		file:  file,
+		buf:   buf,
+		queue: make(chan loadtest.Result, queueSize),
+		done:  make(chan struct{}),
+	}
+
+	if header.Format == FormatJSONL {
+		r.enc = newJSONLEncoder(buf)
+	} else {
+		r.enc = newBinaryEncoder(buf, header.Start)
+	}
+
+	if err := r.enc.header(header); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write results header: %w", err)
+	}
+
+	go r.write()
+
+	return r, nil
+}
+
+// write drains the queue into the file
+func (r *Recorder) write() {
+	defer close(r.done)
+
+	for result := range r.queue {
+		if r.failed() {
+			continue
+		}
+		if err := r.enc.result(result); err != nil {
+			r.fail(fmt.Errorf("failed to write result: %w", err))
+		}
+	}
+}
+
+// Record queues a single result. It returns the first write error, after
+// which results are no longer recorded.
+func (r *Recorder) Record(result loadtest.Result) error {
+	r.mu.Lock()
+	err := r.err
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case r.queue <- result:
+	default:
+		r.dropped.Add(1)
+	}
+
+	return nil
+}
+
+func (r *Recorder) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *Recorder) failed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.err != nil
+}
+
+// Close waits for queued results, writes the trailer and closes the file.
+// Record must not be called afterwards.
+func (r *Recorder) Close(trailer Trailer) error {
+	defer r.file.Close()
+
+	close(r.queue)
+	<-r.done
+
+	if r.failed() {
+		return r.err
+	}
+
+	trailer.Dropped = r.dropped.Load()
+	if err := r.enc.trailer(trailer); err != nil {
+		return fmt.Errorf("failed to write results trailer: %w", err)
+	}
+	if err := r.buf.Flush(); err != nil {
+		return fmt.Errorf("failed to flush results file: %w", err)
+	}
+
+	return r.file.Close()
+}
+
+// Dropped returns the number of results lost because the writer fell behind
+func (r *Recorder) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// Reader reads a results file written by Recorder in either format
+type Reader struct {
+	file   *os.File
+	dec    decoder
+	header Header
+}
+
+// Open opens a results file, detects its format and reads its header
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results file: %w", err)
+	}
+
+	buf := bufio.NewReaderSize(file, 256*1024)
+	r := &Reader{file: file}
+
+	if magic, _ := buf.Peek(len(binaryMagic)); bytes.Equal(magic, binaryMagic) {
+		r.dec = newBinaryDecoder(buf)
+	} else {
+		r.dec = newJSONLDecoder(buf)
+	}
+
+	r.header, err = r.dec.header()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s is not a stresstea results file: %w", path, err)
+	}
+	if r.header.Version > Version {
+		file.Close()
+		return nil, fmt.Errorf("%s was written by a newer stresstea (results version %d)", path, r.header.Version)
+	}
+	if r.header.Config == nil || r.header.Config.Test == nil {
+		file.Close()
+		return nil, fmt.Errorf("%s has no run configuration", path)
+	}
+
+	return r, nil
+}
+
+// Header returns the description of the recorded run
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next result. It returns io.EOF after the last one.
+func (r *Reader) Next() (loadtest.Result, error) {
+	result, err := r.dec.next()
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		// A run that was killed ends without a trailer, possibly mid-record
+		return loadtest.Result{}, io.EOF
+	}
+	if err != nil {
+		return loadtest.Result{}, fmt.Errorf("failed to read result: %w", err)
+	}
+
+	return result, nil
+}
+
+// Trailer returns the final state of the run once every result has been
+// read, or nil when the file ends without one
+func (r *Reader) Trailer() *Trailer {
+	return r.dec.trailer()
+}
+
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
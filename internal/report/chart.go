@@ -0,0 +1,207 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+)
+
+// Charts are rendered as inline SVG so the HTML report needs no scripts or
+// external resources.
+
+const (
+	chartWidth  = 900
+	chartHeight = 260
+	chartLeft   = 64
+	chartRight  = 16
+	chartTop    = 16
+	chartBottom = 32
+)
+
+// chartSeries is one line of a line chart
+type chartSeries struct {
+	Name   string
+	Color  string
+	Values []float64
+	Dashed bool
+}
+
+// lineChart plots series over the run, one value per step
+func lineChart(series []chartSeries, interval time.Duration, formatY func(float64) string) template.HTML {
+	points := 0
+	maxY := 0.0
+	for _, s := range series {
+		points = max(points, len(s.Values))
+		for _, v := range s.Values {
+			maxY = math.Max(maxY, v)
+		}
+	}
+	if points == 0 {
+		return ""
+	}
+	maxY = niceCeil(maxY)
+
+	plotW := float64(chartWidth - chartLeft - chartRight)
+	plotH := float64(chartHeight - chartTop - chartBottom)
+	x := func(i int) float64 {
+		if points == 1 {
+			return chartLeft + plotW/2
+		}
+		return chartLeft + plotW*float64(i)/float64(points-1)
+	}
+	y := func(v float64) float64 {
+		return chartTop + plotH - plotH*v/maxY
+	}
+
+	var b strings.Builder
+	openSVG(&b)
+	yAxis(&b, maxY, formatY, y)
+
+	// Time labels along the X axis
+	step := max(1, niceCeilInt(points/8))
+	for i := 0; i < points; i += step {
+		label := (time.Duration(i) * interval).String()
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" class="axis" text-anchor="middle">%s</text>`,
+			x(i), chartHeight-chartBottom+18, label)
+	}
+
+	for _, s := range series {
+		coords := make([]string, 0, len(s.Values))
+		for i, v := range s.Values {
+			coords = append(coords, fmt.Sprintf("%.1f,%.1f", x(i), y(v)))
+		}
+		dash := ""
+		if s.Dashed {
+			dash = ` stroke-dasharray="6 4"`
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2"%s points="%s"><title>%s</title></polyline>`,
+			s.Color, dash, strings.Join(coords, " "), html.EscapeString(s.Name))
+	}
+
+	legend(&b, series)
+	b.WriteString(`</svg>`)
+
+	return template.HTML(b.String())
+}
+
+// histogramChart draws the latency distribution as bars
+func histogramChart(bins []HistogramBin) template.HTML {
+	if len(bins) == 0 {
+		return ""
+	}
+
+	var maxCount int64
+	for _, bin := range bins {
+		maxCount = max(maxCount, bin.Count)
+	}
+	maxY := niceCeil(float64(maxCount))
+
+	plotW := float64(chartWidth - chartLeft - chartRight)
+	plotH := float64(chartHeight - chartTop - chartBottom)
+	barW := plotW / float64(len(bins))
+	y := func(v float64) float64 {
+		return chartTop + plotH - plotH*v/maxY
+	}
+
+	var b strings.Builder
+	openSVG(&b)
+	yAxis(&b, maxY, func(v float64) string { return fmt.Sprintf("%.0f", v) }, y)
+
+	lower := time.Duration(0)
+	for i, bin := range bins {
+		left := chartLeft + barW*float64(i)
+		top := y(float64(bin.Count))
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#7D56F4"><title>%s - %s: %d</title></rect>`,
+			left+1, top, math.Max(barW-2, 1), chartTop+plotH-top,
+			FormatDuration(lower), FormatDuration(bin.Upper), bin.Count)
+		if i%8 == 0 || i == len(bins)-1 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" class="axis" text-anchor="middle">%s</text>`,
+				left+barW/2, chartHeight-chartBottom+18, FormatDuration(bin.Upper))
+		}
+		lower = bin.Upper
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// barChart draws labelled horizontal bars
+func barChart(labels []string, values []int64, colors []string) template.HTML {
+	if len(values) == 0 {
+		return ""
+	}
+
+	var total, maxValue int64
+	for _, v := range values {
+		total += v
+		maxValue = max(maxValue, v)
+	}
+
+	const rowH = 28
+	height := len(values)*rowH + chartTop
+	plotW := float64(chartWidth - chartLeft - chartRight - 160)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" width="100%%" role="img" xmlns="http://www.w3.org/2000/svg">`, chartWidth, height)
+	for i, v := range values {
+		top := chartTop/2 + i*rowH
+		width := plotW * float64(v) / float64(max(maxValue, 1))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" class="axis" text-anchor="end">%s</text>`,
+			chartLeft-8, top+rowH/2+4, html.EscapeString(labels[i]))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"></rect>`,
+			chartLeft, top+4, math.Max(width, 1), rowH-8, colors[i])
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" class="axis">%d (%.2f%%)</text>`,
+			float64(chartLeft)+width+8, top+rowH/2+4, v, share(v, total))
+	}
+	b.WriteString(`</svg>`)
+
+	return template.HTML(b.String())
+}
+
+func openSVG(b *strings.Builder) {
+	fmt.Fprintf(b, `<svg viewBox="0 0 %d %d" width="100%%" role="img" xmlns="http://www.w3.org/2000/svg">`, chartWidth, chartHeight)
+}
+
+// yAxis draws horizontal grid lines with value labels
+func yAxis(b *strings.Builder, maxY float64, format func(float64) string, y func(float64) float64) {
+	const ticks = 4
+	for i := 0; i <= ticks; i++ {
+		v := maxY * float64(i) / ticks
+		fmt.Fprintf(b, `<line x1="%d" x2="%d" y1="%.1f" y2="%.1f" class="grid"></line>`,
+			chartLeft, chartWidth-chartRight, y(v), y(v))
+		fmt.Fprintf(b, `<text x="%d" y="%.1f" class="axis" text-anchor="end">%s</text>`,
+			chartLeft-8, y(v)+4, format(v))
+	}
+}
+
+func legend(b *strings.Builder, series []chartSeries) {
+	x := chartLeft + 8
+	for _, s := range series {
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="12" height="3" fill="%s"></rect>`, x, chartTop+4, s.Color)
+		fmt.Fprintf(b, `<text x="%d" y="%d" class="legend">%s</text>`, x+16, chartTop+9, html.EscapeString(s.Name))
+		x += 24 + 8*len(s.Name)
+	}
+}
+
+// niceCeil rounds v up to 1, 2 or 5 times a power of ten
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if v <= m*magnitude {
+			return m * magnitude
+		}
+	}
+
+	return 10 * magnitude
+}
+
+func niceCeilInt(v int) int {
+	return int(niceCeil(float64(v)))
+}
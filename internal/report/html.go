@@ -0,0 +1,305 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/timeseries"
+	"gopkg.in/yaml.v3"
+)
+
+// WriteHTML renders the summary as a standalone HTML page
+func WriteHTML(w io.Writer, s Summary) error {
+	return htmlTemplate.Execute(w, newHTMLData(s))
+}
+
+// htmlData is the view model of the HTML report
+type htmlData struct {
+	Summary
+	Latencies   []htmlLatency
+	StatusCodes []htmlCount
+	GRPCCodes   []htmlCount
+	ConfigYAML  string
+
+	RPSChart       template.HTML
+	LatencyChart   template.HTML
+	HistogramChart template.HTML
+	StatusChart    template.HTML
+}
+
+type htmlLatency struct {
+	Name  string
+	Stats aggregator.LatencyStats
+}
+
+type htmlCount struct {
+	Name  string
+	Count int64
+	Share float64
+}
+
+func newHTMLData(s Summary) htmlData {
+	data := htmlData{
+		Summary: s,
+		Latencies: []htmlLatency{
+			{Name: "Service time", Stats: s.Latency},
+			{Name: "Response time", Stats: s.ResponseTime},
+		},
+	}
+
+	if s.Iterations > 0 {
+		data.Latencies = append(data.Latencies, htmlLatency{Name: "Iteration", Stats: s.IterationDuration})
+	}
+	if s.Streams > 0 || s.StreamMessages > 0 {
+		data.Latencies = append(data.Latencies,
+			htmlLatency{Name: "Stream setup", Stats: s.StreamSetup},
+			htmlLatency{Name: "Stream message", Stats: s.MessageLatency})
+	}
+	if p := s.HTTPPhases; p != nil {
+		phases := []htmlLatency{
+			{Name: "DNS lookup", Stats: p.DNS},
+			{Name: "TCP connect", Stats: p.Connect},
+			{Name: "TLS handshake", Stats: p.TLS},
+			{Name: "Time to first byte", Stats: p.TTFB},
+			{Name: "Transfer", Stats: p.Transfer},
+		}
+		for _, phase := range phases {
+			// Connection phases that never happened are skipped
+			if phase.Stats.Max > 0 {
+				data.Latencies = append(data.Latencies, phase)
+			}
+		}
+	}
+
+	codes := make([]int, 0, len(s.StatusCodes))
+	for code := range s.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		data.StatusCodes = append(data.StatusCodes, htmlCount{
+			Name:  strconv.Itoa(code),
+			Count: s.StatusCodes[code],
+			Share: share(s.StatusCodes[code], s.Requests),
+		})
+	}
+
+	if s.Config != nil {
+		if config, err := yaml.Marshal(s.Config); err == nil {
+			data.ConfigYAML = string(config)
+		}
+	}
+
+	data.RPSChart, data.LatencyChart = timelineCharts(s.Timeline, s.Interval, s.TargetIterations())
+	data.HistogramChart = histogramChart(s.Histogram)
+	data.StatusChart = statusChart(data.StatusCodes)
+
+	names := make([]string, 0, len(s.GRPCCodes))
+	for name := range s.GRPCCodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data.GRPCCodes = append(data.GRPCCodes, htmlCount{Name: name, Count: s.GRPCCodes[name]})
+	}
+
+	return data
+}
+
+// timelineCharts plots throughput and latency percentiles over the run. The
+// target of scenario runs is in iterations/s and is labelled so.
+func timelineCharts(timeline []TimelinePoint, interval time.Duration, targetIterations bool) (template.HTML, template.HTML) {
+	if len(timeline) == 0 {
+		return "", ""
+	}
+	if interval <= 0 {
+		interval = timeseries.DefaultInterval
+	}
+
+	var rps, errors, target, p50, p90, p99 []float64
+	hasTarget := false
+	seconds := interval.Seconds()
+	for _, p := range timeline {
+		rps = append(rps, float64(p.Requests)/seconds)
+		errors = append(errors, float64(p.Errors)/seconds)
+		target = append(target, p.Target)
+		p50 = append(p50, float64(p.P50))
+		p90 = append(p90, float64(p.P90))
+		p99 = append(p99, float64(p.P99))
+		hasTarget = hasTarget || p.Target > 0
+	}
+
+	throughput := []chartSeries{
+		{Name: "requests/s", Color: "#7D56F4", Values: rps},
+		{Name: "errors/s", Color: "#E5484D", Values: errors},
+	}
+	if hasTarget {
+		name := "target"
+		if targetIterations {
+			name = "target iterations/s"
+		}
+		throughput = append(throughput, chartSeries{Name: name, Color: "#8D8D8D", Values: target, Dashed: true})
+	}
+
+	latency := []chartSeries{
+		{Name: "p50", Color: "#30A46C", Values: p50},
+		{Name: "p90", Color: "#F5A524", Values: p90},
+		{Name: "p99", Color: "#E5484D", Values: p99},
+	}
+
+	formatRate := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	formatLatency := func(v float64) string { return FormatDuration(time.Duration(v)) }
+
+	return lineChart(throughput, interval, formatRate), lineChart(latency, interval, formatLatency)
+}
+
+// statusChart draws the share of every status code, colored by class
+func statusChart(codes []htmlCount) template.HTML {
+	labels := make([]string, 0, len(codes))
+	values := make([]int64, 0, len(codes))
+	colors := make([]string, 0, len(codes))
+	for _, code := range codes {
+		labels = append(labels, code.Name)
+		values = append(values, code.Count)
+
+		switch code.Name[0] {
+		case '2':
+			colors = append(colors, "#30A46C")
+		case '3':
+			colors = append(colors, "#0091FF")
+		case '4':
+			colors = append(colors, "#F5A524")
+		default:
+			colors = append(colors, "#E5484D")
+		}
+	}
+
+	return barChart(labels, values, colors)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration": FormatDuration,
+	"bytes":    FormatBytes,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Stresstea report - {{.Target}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 2rem auto; max-width: 960px; color: #222; }
+h1 { background: #7D56F4; color: #fafafa; padding: .5rem 1rem; border-radius: 4px; }
+h2 { border-bottom: 2px solid #7D56F4; padding-bottom: .25rem; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5rem; }
+th, td { text-align: left; padding: .35rem .75rem; border-bottom: 1px solid #ddd; }
+th { background: #f4f1fe; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.aborted { color: #c00; font-weight: bold; }
+svg { display: block; margin-bottom: 1.5rem; }
+svg .grid { stroke: #e4e4e4; stroke-width: 1; }
+svg .axis { font-size: 11px; fill: #666; }
+svg .legend { font-size: 12px; fill: #222; }
+pre { background: #f6f6f6; padding: 1rem; border-radius: 4px; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>Stresstea report</h1>
+
+<h2>Run</h2>
+<table>
+<tr><th>Target</th><td>{{.Target}}</td></tr>
+<tr><th>Protocol</th><td>{{.Protocol}}</td></tr>
+<tr><th>Executor</th><td>{{.Executor}}</td></tr>
+<tr><th>Duration</th><td>{{duration .Duration}}</td></tr>
+{{- if .Aborted}}
+<tr><th>Aborted</th><td class="aborted">{{.Aborted}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Requests</h2>
+<table>
+<tr><th>Requests</th><td class="num">{{.Requests}}</td></tr>
+<tr><th>Successful</th><td class="num">{{.Successful}}</td></tr>
+<tr><th>Failed</th><td class="num">{{.Failed}}</td></tr>
+<tr><th>Error rate</th><td class="num">{{printf "%.2f%%" .ErrorRate}}</td></tr>
+<tr><th>Throughput</th><td class="num">{{printf "%.1f" .RPS}} RPS{{if and (gt .TargetRPS 0.0) (not .TargetIterations)}} (target {{printf "%.1f" .TargetRPS}}){{end}}</td></tr>
+<tr><th>Data received</th><td class="num">{{bytes .Bytes}}</td></tr>
+{{- with .HTTPPhases}}
+<tr><th>Reused connections</th><td class="num">{{printf "%.2f%%" .ReuseRate}}</td></tr>
+{{- end}}
+{{- if .Iterations}}
+<tr><th>Iterations</th><td class="num">{{.Iterations}} ({{printf "%.1f" .IterationsPerSecond}}/s{{if and (gt .TargetRPS 0.0) .TargetIterations}}, target {{printf "%.1f" .TargetRPS}}/s{{end}})</td></tr>
+{{- end}}
+{{- if or .Scheduler.Late .Scheduler.Dropped}}
+<tr><th>Scheduler</th><td class="num">{{.Scheduler.Late}} late, {{.Scheduler.Dropped}} dropped</td></tr>
+{{- end}}
+</table>
+
+{{- if .RPSChart}}
+<h2>Throughput over time</h2>
+{{.RPSChart}}
+{{- end}}
+
+<h2>Latency</h2>
+<table>
+<tr><th></th><th>min</th><th>mean</th><th>p50</th><th>p90</th><th>p95</th><th>p99</th><th>max</th></tr>
+{{- range .Latencies}}
+<tr><th>{{.Name}}</th>
+<td class="num">{{duration .Stats.Min}}</td><td class="num">{{duration .Stats.Mean}}</td>
+<td class="num">{{duration .Stats.P50}}</td><td class="num">{{duration .Stats.P90}}</td>
+<td class="num">{{duration .Stats.P95}}</td><td class="num">{{duration .Stats.P99}}</td>
+<td class="num">{{duration .Stats.Max}}</td></tr>
+{{- end}}
+</table>
+
+{{- if .LatencyChart}}
+<h2>Latency percentiles over time</h2>
+{{.LatencyChart}}
+{{- end}}
+
+{{- if .HistogramChart}}
+<h2>Latency distribution</h2>
+{{.HistogramChart}}
+{{- end}}
+
+{{- if .StatusCodes}}
+<h2>Status codes</h2>
+{{.StatusChart}}
+<table>
+<tr><th>Status</th><th>Count</th><th>Share</th></tr>
+{{- range .StatusCodes}}
+<tr><td>{{.Name}}</td><td class="num">{{.Count}}</td><td class="num">{{printf "%.2f%%" .Share}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+{{- if .GRPCCodes}}
+<h2>gRPC codes</h2>
+<table>
+<tr><th>Code</th><th>Count</th></tr>
+{{- range .GRPCCodes}}
+<tr><td>{{.Name}}</td><td class="num">{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+{{- if .Errors}}
+<h2>Errors</h2>
+<table>
+<tr><th>Error</th><th>Count</th></tr>
+{{- range .Errors}}
+<tr><td>{{.Message}}</td><td class="num">{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+{{- if .ConfigYAML}}
+<h2>Configuration</h2>
+<pre>{{.ConfigYAML}}</pre>
+{{- end}}
+</body>
+</html>
+`))
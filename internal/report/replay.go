@@ -0,0 +1,55 @@
+package report
+
+import (
+	"errors"
+	"io"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"github.com/paniccaaa/stresstea/internal/recorder"
+)
+
+// Replay rebuilds the summary of a run from its recorded results
+func Replay(path string) (Summary, error) {
+	agg, stats, err := Load(path)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	return NewSummary(agg, stats), nil
+}
+
+// Load feeds the recorded results of a run into a new aggregator and returns
+// it with the scheduler statistics of the run
+func Load(path string) (*aggregator.Aggregator, loadtest.SchedulerStats, error) {
+	var stats loadtest.SchedulerStats
+
+	reader, err := recorder.Open(path)
+	if err != nil {
+		return nil, stats, err
+	}
+	defer reader.Close()
+
+	header := reader.Header()
+	agg := aggregator.New(header.Config, header.Start)
+
+	for {
+		result, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, stats, err
+		}
+		agg.Add(result)
+	}
+
+	if trailer := reader.Trailer(); trailer != nil {
+		stats = trailer.Scheduler
+		if trailer.Aborted != "" {
+			agg.Abort(trailer.Aborted)
+		}
+	}
+
+	return agg, stats, nil
+}
@@ -0,0 +1,122 @@
+package report
+
+import (
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"github.com/paniccaaa/stresstea/internal/parser"
+)
+
+// Summary is the end-of-run digest of a load test
+type Summary struct {
+	Target    string        `json:"target"`
+	Protocol  string        `json:"protocol"`
+	Executor  string        `json:"executor"`
+	TargetRPS float64       `json:"target_rps"` // average target rate, 0 for closed-model runs; see TargetIterations
+	Duration  time.Duration `json:"duration"`
+	Aborted   string        `json:"aborted,omitempty"` // why the run was stopped early
+	Arrival   string        `json:"arrival,omitempty"` // random arrival distribution, empty for constant
+	Seed      uint64        `json:"seed,omitempty"`    // seed the arrival distribution ran with
+
+	Requests   int64   `json:"requests"`
+	Successful int64   `json:"successful"`
+	Failed     int64   `json:"failed"`
+	ErrorRate  float64 `json:"error_rate"` // percent
+	RPS        float64 `json:"rps"`
+	Bytes      int64   `json:"bytes"`
+
+	Latency      aggregator.LatencyStats `json:"latency"`       // service time
+	ResponseTime aggregator.LatencyStats `json:"response_time"` // from the intended start
+
+	StatusCodes map[int]int64           `json:"status_codes,omitempty"`
+	GRPCCodes   map[string]int64        `json:"grpc_codes,omitempty"`
+	Errors      []aggregator.ErrorCount `json:"errors,omitempty"`
+
+	Iterations          int64                   `json:"iterations,omitempty"`
+	IterationsPerSecond float64                 `json:"iterations_per_second,omitempty"`
+	IterationDuration   aggregator.LatencyStats `json:"iteration_duration"`
+
+	Streams        int64                   `json:"streams,omitempty"`
+	StreamErrors   int64                   `json:"stream_errors,omitempty"`
+	StreamMessages int64                   `json:"stream_messages,omitempty"`
+	StreamSetup    aggregator.LatencyStats `json:"stream_setup"`
+	MessageLatency aggregator.LatencyStats `json:"message_latency"`
+
+	// HTTPPhases is nil when no HTTP request was traced
+	HTTPPhases *aggregator.HTTPPhases `json:"http_phases,omitempty"`
+
+	Scheduler loadtest.SchedulerStats `json:"scheduler"`
+
+	Timeline  []TimelinePoint       `json:"timeline,omitempty"`  // per-interval metrics
+	Interval  time.Duration         `json:"interval,omitempty"`  // width of a timeline point
+	Histogram []HistogramBin        `json:"histogram,omitempty"` // service time distribution
+	Config    *parser.TestRunConfig `json:"config,omitempty"`
+}
+
+// TargetIterations reports whether the target rate counts iterations rather
+// than requests: scenario runs schedule whole iterations
+func (s Summary) TargetIterations() bool {
+	return s.Config != nil && len(s.Config.Scenarios) > 0
+}
+
+// NewSummary builds the digest of everything aggregated so far
+func NewSummary(agg *aggregator.Aggregator, stats loadtest.SchedulerStats) Summary {
+	cfg := agg.Config().Test
+	snap := agg.Snapshot()
+
+	s := Summary{
+		Target:            cfg.Target,
+		Protocol:          cfg.Protocol,
+		Executor:          cfg.Executor,
+		Duration:          snap.Elapsed(),
+		Aborted:           snap.Aborted,
+		Requests:          snap.Requests,
+		Successful:        snap.Requests - snap.Failed,
+		Failed:            snap.Failed,
+		Bytes:             snap.Bytes,
+		Latency:           snap.Latency,
+		ResponseTime:      snap.ResponseTime,
+		StatusCodes:       snap.StatusCodes,
+		GRPCCodes:         snap.GRPCCodes,
+		Errors:            snap.Errors,
+		Iterations:        snap.Iterations,
+		IterationDuration: snap.IterationDuration,
+		Streams:           snap.Streams,
+		StreamErrors:      snap.StreamErrors,
+		StreamMessages:    snap.StreamMessages,
+		StreamSetup:       snap.StreamSetup,
+		MessageLatency:    snap.MessageLatency,
+		Scheduler:         stats,
+		Timeline:          buildTimeline(agg.Series(), cfg),
+		Interval:          agg.Series().Interval(),
+		Histogram:         buildHistogram(agg.Latencies()),
+		Config:            cfg,
+	}
+
+	if s.Executor == "" {
+		s.Executor = parser.ExecutorArrivalRate
+	}
+	if s.Executor == parser.ExecutorArrivalRate {
+		s.TargetRPS = loadtest.NewRateProfile(cfg).Average()
+
+		// A zero seed in the configuration is replaced with the generated
+		// one when the run starts, so this is the seed to reproduce it with
+		if a := cfg.Arrival; a != nil && a.Type != "" && a.Type != parser.ArrivalConstant {
+			s.Arrival = a.Type
+			s.Seed = a.Seed
+		}
+	}
+	if snap.HTTP.Requests > 0 {
+		s.HTTPPhases = &snap.HTTP
+	}
+	if s.Requests > 0 {
+		s.ErrorRate = float64(s.Failed) / float64(s.Requests) * 100
+	}
+	if seconds := s.Duration.Seconds(); seconds > 0 {
+		s.RPS = float64(s.Requests) / seconds
+		s.IterationsPerSecond = float64(s.Iterations) / seconds
+	}
+
+	return s
+}
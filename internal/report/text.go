@@ -0,0 +1,173 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+)
+
+// WriteText renders the summary as a human-readable plain text report
+func WriteText(w io.Writer, s Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "Stresstea summary")
+	fmt.Fprintln(tw, strings.Repeat("=", 60))
+	fmt.Fprintf(tw, "Target:\t%s (%s, %s)\n", s.Target, s.Protocol, s.Executor)
+	fmt.Fprintf(tw, "Duration:\t%v\n", s.Duration.Round(time.Millisecond))
+	if s.Arrival != "" {
+		fmt.Fprintf(tw, "Arrival:\t%s (seed %d)\n", s.Arrival, s.Seed)
+	}
+	if s.Aborted != "" {
+		fmt.Fprintf(tw, "Aborted:\t%s\n", s.Aborted)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "Requests:\t%d (%d ok, %d failed)\n", s.Requests, s.Successful, s.Failed)
+	fmt.Fprintf(tw, "Error rate:\t%.2f%%\n", s.ErrorRate)
+	if s.TargetRPS > 0 && !s.TargetIterations() {
+		fmt.Fprintf(tw, "Throughput:\t%.1f RPS (target %.1f)\n", s.RPS, s.TargetRPS)
+	} else {
+		fmt.Fprintf(tw, "Throughput:\t%.1f RPS\n", s.RPS)
+	}
+	fmt.Fprintf(tw, "Data received:\t%s\n", FormatBytes(s.Bytes))
+	if p := s.HTTPPhases; p != nil {
+		fmt.Fprintf(tw, "Connections:\t%.2f%% of requests reused a connection\n", p.ReuseRate())
+	}
+	if s.TargetRPS > 0 && s.TargetIterations() {
+		fmt.Fprintf(tw, "Iterations:\t%d (%.1f/s, target %.1f/s)\n", s.Iterations, s.IterationsPerSecond, s.TargetRPS)
+	} else if s.Iterations > 0 {
+		fmt.Fprintf(tw, "Iterations:\t%d (%.1f/s)\n", s.Iterations, s.IterationsPerSecond)
+	}
+	if s.Scheduler.Late > 0 || s.Scheduler.Dropped > 0 {
+		fmt.Fprintf(tw, "Scheduler:\t%d late, %d dropped\n", s.Scheduler.Late, s.Scheduler.Dropped)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "Latency\tmin\tmean\tp50\tp90\tp95\tp99\tmax")
+	writeLatencyRow(tw, "service time", s.Latency)
+	writeLatencyRow(tw, "response time", s.ResponseTime)
+	if s.Iterations > 0 {
+		writeLatencyRow(tw, "iteration", s.IterationDuration)
+	}
+	if s.Streams > 0 || s.StreamMessages > 0 {
+		writeLatencyRow(tw, "stream setup", s.StreamSetup)
+		writeLatencyRow(tw, "stream message", s.MessageLatency)
+	}
+	if p := s.HTTPPhases; p != nil {
+		// Connection phases that never happened (e.g. TLS over plain HTTP) are skipped
+		if p.DNS.Max > 0 {
+			writeLatencyRow(tw, "dns lookup", p.DNS)
+		}
+		if p.Connect.Max > 0 {
+			writeLatencyRow(tw, "tcp connect", p.Connect)
+		}
+		if p.TLS.Max > 0 {
+			writeLatencyRow(tw, "tls handshake", p.TLS)
+		}
+		writeLatencyRow(tw, "ttfb", p.TTFB)
+		writeLatencyRow(tw, "transfer", p.Transfer)
+	}
+
+	if s.Streams > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "Streams:\t%d (%d failed, %d messages)\n", s.Streams, s.StreamErrors, s.StreamMessages)
+	}
+
+	if len(s.StatusCodes) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "Status code\tcount\tshare")
+		codes := make([]int, 0, len(s.StatusCodes))
+		for code := range s.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(tw, "%d\t%d\t%.2f%%\n", code, s.StatusCodes[code], share(s.StatusCodes[code], s.Requests))
+		}
+	}
+
+	if len(s.GRPCCodes) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "gRPC code\tcount")
+		codes := make([]string, 0, len(s.GRPCCodes))
+		for code := range s.GRPCCodes {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Fprintf(tw, "%s\t%d\n", code, s.GRPCCodes[code])
+		}
+	}
+
+	if len(s.Errors) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "Errors\tcount")
+		for _, e := range s.Errors {
+			fmt.Fprintf(tw, "%s\t%d\n", e.Message, e.Count)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// ProgressLine renders a one-line progress update for headless runs
+func ProgressLine(elapsed, total time.Duration, requests, failed int64, rps float64, inFlight int64, concurrency int) string {
+	progress := 0.0
+	if total > 0 {
+		progress = float64(elapsed) / float64(total) * 100
+		if progress > 100 {
+			progress = 100
+		}
+	}
+
+	return fmt.Sprintf("[%v/%v %5.1f%%] requests=%d failed=%d (%.2f%%) rps=%.1f in-flight=%d/%d",
+		elapsed.Round(time.Second), total, progress,
+		requests, failed, share(failed, requests), rps,
+		inFlight, concurrency)
+}
+
+func writeLatencyRow(w io.Writer, name string, l aggregator.LatencyStats) {
+	fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", name,
+		FormatDuration(l.Min), FormatDuration(l.Mean), FormatDuration(l.P50),
+		FormatDuration(l.P90), FormatDuration(l.P95), FormatDuration(l.P99),
+		FormatDuration(l.Max))
+}
+
+// FormatDuration formats latencies with a unit that keeps them readable
+func FormatDuration(d time.Duration) string {
+	if d < time.Millisecond {
+		return fmt.Sprintf("%.0fμs", float64(d.Nanoseconds())/1000)
+	} else if d < time.Second {
+		return fmt.Sprintf("%.1fms", float64(d.Nanoseconds())/1e6)
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
+
+// FormatBytes formats a byte count with a binary unit
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.2f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// share returns part as a percentage of total
+func share(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"math"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/hdr"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+	"github.com/paniccaaa/stresstea/internal/parser"
+	"github.com/paniccaaa/stresstea/internal/timeseries"
+)
+
+// histogramBins is the number of bins of the latency histogram
+const histogramBins = 40
+
+// TimelinePoint holds the metrics of one time-series bucket
+type TimelinePoint struct {
+	timeseries.Point
+	Target float64 `json:"target,omitempty"` // target rate, 0 for closed-model runs
+}
+
+// HistogramBin counts latencies in (previous bin's Upper, Upper]
+type HistogramBin struct {
+	Upper time.Duration `json:"upper"`
+	Count int64         `json:"count"`
+}
+
+// buildTimeline adds the target rate of open-model runs to the series points
+func buildTimeline(series *timeseries.Series, cfg *parser.TestRunConfig) []TimelinePoint {
+	var profile *loadtest.RateProfile
+	if cfg.Executor != parser.ExecutorVUs {
+		profile = loadtest.NewRateProfile(cfg)
+	}
+
+	points := series.Points()
+	timeline := make([]TimelinePoint, 0, len(points))
+	for _, p := range points {
+		point := TimelinePoint{Point: p}
+		if profile != nil {
+			point.Target = profile.At(p.Offset + series.Interval()/2).Rate
+		}
+		timeline = append(timeline, point)
+	}
+
+	return timeline
+}
+
+// buildHistogram spreads latencies over logarithmically sized bins
+func buildHistogram(h *hdr.Histogram) []HistogramBin {
+	if h.Count() == 0 {
+		return nil
+	}
+
+	low := math.Max(float64(h.Min()), float64(time.Microsecond))
+	high := math.Max(float64(h.Max()), low*1.01)
+	ratio := math.Pow(high/low, 1.0/histogramBins)
+
+	bins := make([]HistogramBin, histogramBins)
+	upper := low
+	for i := range bins {
+		upper *= ratio
+		bins[i].Upper = time.Duration(upper)
+	}
+	bins[len(bins)-1].Upper = h.Max()
+
+	next := 0
+	h.ForEach(func(value time.Duration, count int64) {
+		for next < len(bins)-1 && value > bins[next].Upper {
+			next++
+		}
+		bins[next].Count += count
+	})
+
+	return bins
+}
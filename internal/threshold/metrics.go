@@ -0,0 +1,169 @@
+package threshold
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/paniccaaa/stresstea/internal/aggregator"
+	"github.com/paniccaaa/stresstea/internal/report"
+)
+
+// kind defines how a metric's threshold value is written
+type kind int
+
+const (
+	kindDuration kind = iota // "300ms"
+	kindPercent              // "1%" or "1", always a percentage
+	kindCount                // "10", or "95%" of the metric's reference
+)
+
+// metric extracts a value from the summary
+type metric struct {
+	kind      kind
+	value     func(s report.Summary) float64
+	reference func(s report.Summary) float64 // base for percentages, nil when not supported
+
+	cumulative  bool // grows over the run, so an absolute limit means nothing mid-run
+	targetBased bool // the reference is the target rate, which closed-model runs lack
+}
+
+// latencies maps latency statistic names to their fields
+var latencies = map[string]func(l aggregator.LatencyStats) float64{
+	"min":  func(l aggregator.LatencyStats) float64 { return float64(l.Min) },
+	"mean": func(l aggregator.LatencyStats) float64 { return float64(l.Mean) },
+	"p50":  func(l aggregator.LatencyStats) float64 { return float64(l.P50) },
+	"p90":  func(l aggregator.LatencyStats) float64 { return float64(l.P90) },
+	"p95":  func(l aggregator.LatencyStats) float64 { return float64(l.P95) },
+	"p99":  func(l aggregator.LatencyStats) float64 { return float64(l.P99) },
+	"max":  func(l aggregator.LatencyStats) float64 { return float64(l.Max) },
+}
+
+// distributions maps latency metric prefixes to the distribution they read;
+// unprefixed latency metrics read the service time
+var distributions = map[string]func(s report.Summary) aggregator.LatencyStats{
+	"response_": func(s report.Summary) aggregator.LatencyStats { return s.ResponseTime },
+	"dns_":      httpPhase(func(p *aggregator.HTTPPhases) aggregator.LatencyStats { return p.DNS }),
+	"connect_":  httpPhase(func(p *aggregator.HTTPPhases) aggregator.LatencyStats { return p.Connect }),
+	"tls_":      httpPhase(func(p *aggregator.HTTPPhases) aggregator.LatencyStats { return p.TLS }),
+	"ttfb_":     httpPhase(func(p *aggregator.HTTPPhases) aggregator.LatencyStats { return p.TTFB }),
+	"transfer_": httpPhase(func(p *aggregator.HTTPPhases) aggregator.LatencyStats { return p.Transfer }),
+}
+
+// httpPhase reads an HTTP phase, zero when no HTTP request was traced
+func httpPhase(phase func(p *aggregator.HTTPPhases) aggregator.LatencyStats) func(s report.Summary) aggregator.LatencyStats {
+	return func(s report.Summary) aggregator.LatencyStats {
+		if s.HTTPPhases == nil {
+			return aggregator.LatencyStats{}
+		}
+		return phase(s.HTTPPhases)
+	}
+}
+
+func requests(s report.Summary) float64 { return float64(s.Requests) }
+
+// targetRequestRate is the base of "rps >= N%". Scenario runs schedule
+// iterations, not requests, so the target is scaled by the requests each
+// iteration made: the share then compares iterations/s with the target.
+func targetRequestRate(s report.Summary) float64 {
+	if s.Iterations > 0 && s.IterationsPerSecond > 0 {
+		return s.TargetRPS * s.RPS / s.IterationsPerSecond
+	}
+	return s.TargetRPS
+}
+
+// metrics are the fixed-name metrics; latencies and status codes are resolved by lookup
+var metrics = map[string]metric{
+	"error_rate": {kind: kindPercent, value: func(s report.Summary) float64 { return s.ErrorRate }},
+	"requests":   {kind: kindCount, value: requests, cumulative: true},
+	"failed": {
+		kind:       kindCount,
+		value:      func(s report.Summary) float64 { return float64(s.Failed) },
+		reference:  requests,
+		cumulative: true,
+	},
+	"rps": {
+		kind:        kindCount,
+		value:       func(s report.Summary) float64 { return s.RPS },
+		reference:   targetRequestRate,
+		targetBased: true,
+	},
+	"dropped": {
+		kind:       kindCount,
+		value:      func(s report.Summary) float64 { return float64(s.Scheduler.Dropped) },
+		reference:  func(s report.Summary) float64 { return float64(s.Scheduler.Scheduled) },
+		cumulative: true,
+	},
+	"late": {
+		kind:       kindCount,
+		value:      func(s report.Summary) float64 { return float64(s.Scheduler.Late) },
+		reference:  func(s report.Summary) float64 { return float64(s.Scheduler.Scheduled) },
+		cumulative: true,
+	},
+}
+
+// lookup resolves a metric name:
+//   - p95, mean, ... - service time; response_p95, ... - response time
+//   - dns_, connect_, tls_, ttfb_, transfer_ + p95, ... - HTTP request phases
+//   - status_503 or status_5xx - number of responses with the status
+//   - error_rate, requests, failed, rps, dropped, late
+func lookup(name string) (metric, bool) {
+	if m, ok := metrics[name]; ok {
+		return m, true
+	}
+
+	if stat, ok := latencies[name]; ok {
+		return durationMetric(func(s report.Summary) float64 { return stat(s.Latency) }), true
+	}
+	for prefix, distribution := range distributions {
+		if stat, ok := latencies[strings.TrimPrefix(name, prefix)]; ok && strings.HasPrefix(name, prefix) {
+			return durationMetric(func(s report.Summary) float64 { return stat(distribution(s)) }), true
+		}
+	}
+
+	if code, ok := strings.CutPrefix(name, "status_"); ok {
+		return statusMetric(code)
+	}
+
+	return metric{}, false
+}
+
+func durationMetric(value func(s report.Summary) float64) metric {
+	return metric{kind: kindDuration, value: value}
+}
+
+// statusMetric counts responses with an exact code ("503") or a class ("5xx")
+func statusMetric(code string) (metric, bool) {
+	if len(code) != 3 {
+		return metric{}, false
+	}
+
+	var match func(status int) bool
+	if class, ok := strings.CutSuffix(code, "xx"); ok {
+		n, err := strconv.Atoi(class)
+		if err != nil || n < 1 || n > 5 {
+			return metric{}, false
+		}
+		match = func(status int) bool { return status/100 == n }
+	} else {
+		n, err := strconv.Atoi(code)
+		if err != nil || n < 100 {
+			return metric{}, false
+		}
+		match = func(status int) bool { return status == n }
+	}
+
+	return metric{
+		kind: kindCount,
+		value: func(s report.Summary) float64 {
+			var count int64
+			for status, n := range s.StatusCodes {
+				if match(status) {
+					count += n
+				}
+			}
+			return float64(count)
+		},
+		reference:  requests,
+		cumulative: true,
+	}, true
+}
@@ -0,0 +1,36 @@
+package threshold
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteText renders the pass/fail table of evaluated thresholds
+func WriteText(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "Thresholds")
+	fmt.Fprintln(tw, strings.Repeat("=", 60))
+
+	failed := 0
+	for _, r := range results {
+		status := "PASS"
+		if !r.Pass {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", status, r.Expr, r.FormatActual())
+	}
+
+	fmt.Fprintln(tw)
+	if failed > 0 {
+		fmt.Fprintf(tw, "%d of %d thresholds failed\n", failed, len(results))
+	} else {
+		fmt.Fprintf(tw, "All %d thresholds passed\n", len(results))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,190 @@
+package threshold
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/parser"
+	"github.com/paniccaaa/stresstea/internal/report"
+)
+
+// ErrFailed is returned by a run that completed but broke its thresholds
+var ErrFailed = errors.New("thresholds failed")
+
+// expression matches checks such as "p95 < 300ms" or "rps >= 95%"
+var expression = regexp.MustCompile(`^\s*([a-z0-9_]+)\s*(<=|>=|==|!=|<|>)\s*(\S+)\s*$`)
+
+// Threshold is a parsed pass/fail check
+type Threshold struct {
+	Expr    string
+	Metric  string
+	Op      string
+	Value   float64 // nanoseconds for latencies, percent when Percent is set
+	Percent bool    // the value is a share of the metric's reference
+
+	AbortOnFail bool          // evaluated during the run, stops it on failure
+	Grace       time.Duration // no evaluation during the run before this
+
+	metric metric
+}
+
+// Result is the outcome of a single threshold
+type Result struct {
+	Threshold
+	Actual float64 // in the unit of Value
+	Pass   bool
+	Note   string // why the threshold could not be evaluated
+}
+
+// Parse parses a check such as "p95 < 300ms", "error_rate < 1%" or "status_5xx < 10"
+func Parse(expr string) (Threshold, error) {
+	match := expression.FindStringSubmatch(expr)
+	if match == nil {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: expected '<metric> <op> <value>'", expr)
+	}
+
+	m, ok := lookup(match[1])
+	if !ok {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: unknown metric %q", expr, match[1])
+	}
+
+	t := Threshold{
+		Expr:   strings.TrimSpace(expr),
+		Metric: match[1],
+		Op:     match[2],
+		metric: m,
+	}
+
+	raw := match[3]
+	var err error
+	switch m.kind {
+	case kindDuration:
+		var d time.Duration
+		d, err = time.ParseDuration(raw)
+		t.Value = float64(d)
+	case kindPercent:
+		t.Percent = true
+		t.Value, err = strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+	case kindCount:
+		if strings.HasSuffix(raw, "%") {
+			if m.reference == nil {
+				return Threshold{}, fmt.Errorf("invalid threshold %q: %s does not support percentages", expr, t.Metric)
+			}
+			t.Percent = true
+			raw = strings.TrimSuffix(raw, "%")
+		}
+		t.Value, err = strconv.ParseFloat(raw, 64)
+	}
+	if err != nil {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: bad value %q", expr, match[3])
+	}
+
+	return t, nil
+}
+
+// ParseAll parses the thresholds configured for a test run. It rejects checks
+// the run can never evaluate: percentages of the target rate in closed-model
+// runs, and abort_on_fail on absolute limits of counts that only grow.
+func ParseAll(test *parser.TestRunConfig) ([]Threshold, error) {
+	if test == nil {
+		return nil, nil
+	}
+
+	thresholds := make([]Threshold, 0, len(test.Thresholds))
+	for _, cfg := range test.Thresholds {
+		t, err := Parse(cfg.Check)
+		if err != nil {
+			return nil, err
+		}
+		if t.Percent && t.metric.targetBased && test.Executor == parser.ExecutorVUs {
+			return nil, fmt.Errorf("invalid threshold %q: the %s executor has no target rate to compare with", t.Expr, parser.ExecutorVUs)
+		}
+		if cfg.AbortOnFail && !t.Percent && t.metric.cumulative {
+			return nil, fmt.Errorf("invalid threshold %q: abort_on_fail needs a percentage, the %s total only grows during the run", t.Expr, t.Metric)
+		}
+		t.AbortOnFail = cfg.AbortOnFail
+		t.Grace = cfg.Grace
+		thresholds = append(thresholds, t)
+	}
+
+	return thresholds, nil
+}
+
+// Evaluate checks every threshold against the summary
+func Evaluate(thresholds []Threshold, s report.Summary) []Result {
+	results := make([]Result, 0, len(thresholds))
+	for _, t := range thresholds {
+		results = append(results, t.Evaluate(s))
+	}
+
+	return results
+}
+
+// Evaluate checks the threshold against the summary
+func (t Threshold) Evaluate(s report.Summary) Result {
+	r := Result{Threshold: t}
+
+	actual := t.metric.value(s)
+	if t.Percent && t.metric.kind == kindCount {
+		reference := t.metric.reference(s)
+		if reference <= 0 {
+			r.Note = "no reference value"
+			return r
+		}
+		actual = actual / reference * 100
+	}
+
+	r.Actual = actual
+	r.Pass = compare(actual, t.Op, t.Value)
+
+	return r
+}
+
+// Passed reports whether every threshold passed
+func Passed(results []Result) bool {
+	for _, r := range results {
+		if !r.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatActual renders the measured value in the unit of the threshold
+func (r Result) FormatActual() string {
+	switch {
+	case r.Note != "":
+		return r.Note
+	case r.metric.kind == kindDuration:
+		return report.FormatDuration(time.Duration(r.Actual))
+	case r.Percent:
+		return fmt.Sprintf("%.2f%%", r.Actual)
+	case r.Actual == float64(int64(r.Actual)):
+		return strconv.FormatInt(int64(r.Actual), 10)
+	default:
+		return fmt.Sprintf("%.1f", r.Actual)
+	}
+}
+
+func compare(actual float64, op string, value float64) bool {
+	switch op {
+	case "<":
+		return actual < value
+	case "<=":
+		return actual <= value
+	case ">":
+		return actual > value
+	case ">=":
+		return actual >= value
+	case "==":
+		return actual == value
+	case "!=":
+		return actual != value
+	default:
+		return false
+	}
+}
@@ -0,0 +1,173 @@
+// Package timeseries splits a run into fixed-width time buckets of request
+// counts, errors, bytes and latency percentiles. The engine keeps a single
+// series per run and the TUI, reports and exporters read snapshots of it.
+package timeseries
+
+import (
+	"sync"
+	"time"
+
+	"github.com/paniccaaa/stresstea/internal/hdr"
+	"github.com/paniccaaa/stresstea/internal/loadtest"
+)
+
+// DefaultInterval is the bucket width used when none is configured
+const DefaultInterval = time.Second
+
+// latencyDigits is the precision of per-bucket latency histograms (1%)
+const latencyDigits = 2
+
+// openBuckets is how many of the newest buckets keep their histograms.
+// Older buckets are reduced to their percentiles, so memory grows by a few
+// numbers per bucket rather than a histogram.
+const openBuckets = 3
+
+// Point holds the metrics of one bucket
+type Point struct {
+	Offset   time.Duration `json:"offset"` // bucket start, from the start of the run
+	Requests int64         `json:"requests"`
+	Errors   int64         `json:"errors"`
+	Bytes    int64         `json:"bytes"`
+	P50      time.Duration `json:"p50"`
+	P90      time.Duration `json:"p90"`
+	P99      time.Duration `json:"p99"`
+}
+
+// bucket accumulates the requests completed within one interval
+type bucket struct {
+	point     Point
+	latencies *hdr.Histogram // nil once the bucket is closed
+}
+
+// Series is safe for concurrent use
+type Series struct {
+	start    time.Time
+	interval time.Duration
+
+	mu      sync.Mutex
+	buckets []*bucket
+}
+
+// New creates a series for a run that started at start. A non-positive
+// interval means DefaultInterval.
+func New(start time.Time, interval time.Duration) *Series {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Series{start: start, interval: interval}
+}
+
+// Interval returns the bucket width
+func (s *Series) Interval() time.Duration {
+	return s.interval
+}
+
+// Add records a request result in the bucket of its completion time.
+// Other result kinds are ignored.
+func (s *Series) Add(result loadtest.Result) {
+	if result.Kind != loadtest.KindRequest {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.bucket(result.Timestamp.Add(result.Latency))
+	b.point.Requests++
+	b.point.Bytes += result.Bytes
+
+	if result.Error != nil {
+		b.point.Errors++
+		return
+	}
+	// A late result for a closed bucket is counted but its latency is lost
+	if b.latencies != nil {
+		b.latencies.Record(result.Latency)
+	}
+}
+
+// Points returns every bucket from the start of the run to the last result
+func (s *Series) Points() []Point {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	points := make([]Point, len(s.buckets))
+	for i, b := range s.buckets {
+		points[i] = b.snapshot()
+	}
+
+	return points
+}
+
+// Completed returns up to n of the newest buckets that ended before now,
+// oldest first. Empty buckets are included so the result has no gaps.
+func (s *Series) Completed(now time.Time, n int) []Point {
+	end := int(now.Sub(s.start) / s.interval) // index of the bucket still in progress
+	if end <= 0 || n <= 0 {
+		return nil
+	}
+	from := max(0, end-n)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	points := make([]Point, 0, end-from)
+	for i := from; i < end; i++ {
+		if i < len(s.buckets) {
+			points = append(points, s.buckets[i].snapshot())
+		} else {
+			points = append(points, Point{Offset: time.Duration(i) * s.interval})
+		}
+	}
+
+	return points
+}
+
+// Rate returns the requests per second of a point
+func (s *Series) Rate(p Point) float64 {
+	return float64(p.Requests) / s.interval.Seconds()
+}
+
+// bucket returns the bucket containing ts, creating it if needed
+func (s *Series) bucket(ts time.Time) *bucket {
+	index := 0
+	if offset := ts.Sub(s.start); offset > 0 {
+		index = int(offset / s.interval)
+	}
+
+	for len(s.buckets) <= index {
+		s.buckets = append(s.buckets, &bucket{
+			point:     Point{Offset: time.Duration(len(s.buckets)) * s.interval},
+			latencies: hdr.New(latencyDigits),
+		})
+		if closed := len(s.buckets) - 1 - openBuckets; closed >= 0 {
+			s.buckets[closed].close()
+		}
+	}
+
+	return s.buckets[index]
+}
+
+// snapshot returns the point with up-to-date percentiles
+func (b *bucket) snapshot() Point {
+	if b.latencies != nil {
+		b.fillPercentiles()
+	}
+	return b.point
+}
+
+// close keeps the percentiles and releases the histogram
+func (b *bucket) close() {
+	if b.latencies == nil {
+		return
+	}
+	b.fillPercentiles()
+	b.latencies = nil
+}
+
+func (b *bucket) fillPercentiles() {
+	b.point.P50 = b.latencies.Percentile(50)
+	b.point.P90 = b.latencies.Percentile(90)
+	b.point.P99 = b.latencies.Percentile(99)
+}
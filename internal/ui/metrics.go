@@ -1,16 +1,20 @@
 package ui
 
 import (
+	"math"
 	"sort"
 	"time"
 
+	"github.com/paniccaaa/stresstea/internal/aggregator"
 	"github.com/paniccaaa/stresstea/internal/loadtest"
 	"github.com/paniccaaa/stresstea/internal/parser"
+	"github.com/paniccaaa/stresstea/internal/timeseries"
 )
 
 // Metrics содержит расширенные метрики для нагрузочного тестирования
 type Metrics struct {
-	config *parser.Config
+	config  *parser.Config
+	profile *loadtest.RateProfile
 
 	// Основные метрики
 	TotalRequests      int
@@ -27,17 +31,47 @@ type Metrics struct {
 	P95Latency time.Duration
 	P99Latency time.Duration
 
+	// Перцентили за последний завершенный интервал временного ряда
+	IntervalP50Latency time.Duration
+	IntervalP99Latency time.Duration
+
+	// Время ответа от запланированного старта (с учетом coordinated omission)
+	P50ResponseTime time.Duration
+	P90ResponseTime time.Duration
+	P95ResponseTime time.Duration
+	P99ResponseTime time.Duration
+
 	// RPS метрики
 	CurrentRPS float64
 	TargetRPS  int
-	RPSHistory []float64 // Последние 60 секунд для графика
+	RPSHistory []float64 // Последние MaxRPSHistory интервалов для графика
+
+	// Текущая стадия профиля нагрузки
+	Stage loadtest.StageInfo
 
-	// Скользящее окно для точного RPS
-	requestTimestamps []time.Time
-	windowSize        time.Duration // Размер окна для расчета RPS (по умолчанию 1 секунда)
+	// Планировщик нагрузки
+	Scheduler loadtest.SchedulerStats
 
 	// Статус коды
 	StatusCodes map[int]int
+	GRPCCodes   map[string]int // gRPC статус коды по имени
+
+	// Итерации (сценарии и режим vus)
+	Iterations           int
+	IterationsPerSecond  float64
+	AvgIterationDuration time.Duration
+	P95IterationDuration time.Duration
+
+	// gRPC стримы
+	Streams           int
+	StreamErrors      int
+	StreamMessages    int
+	AvgStreamSetup    time.Duration
+	P50MessageLatency time.Duration
+	P99MessageLatency time.Duration
+
+	// Фазы HTTP запросов
+	HTTPPhases aggregator.HTTPPhases
 
 	// Ошибки
 	RecentErrors []string // Последние 10 ошибок
@@ -56,110 +90,84 @@ type Metrics struct {
 	ErrorRate         float64
 	ThroughputMBps    float64
 
+	// Источник данных: агрегатор движка и его временной ряд
+	aggregator *aggregator.Aggregator
+	series     *timeseries.Series
+
 	// Состояние теста
 	status TestStatus
 }
 
-// NewMetrics создает новую структуру метрик
-func NewMetrics(config *parser.Config) *Metrics {
+// NewMetrics создает метрики, читающие состояние прогона из агрегатора
+func NewMetrics(config *parser.Config, agg *aggregator.Aggregator) *Metrics {
 	return &Metrics{
-		config:            config,
-		StatusCodes:       make(map[int]int),
-		RPSHistory:        make([]float64, 0, MaxRPSHistory),
-		RecentErrors:      make([]string, 0, MaxErrors),
-		TargetRPS:         config.Test.Rate,
-		StartTime:         time.Now(),
-		requestTimestamps: make([]time.Time, 0, 1000),
-		windowSize:        time.Second, // 1 секунда для расчета RPS
+		config:       config,
+		profile:      loadtest.NewRateProfile(config.Test),
+		StatusCodes:  make(map[int]int),
+		GRPCCodes:    make(map[string]int),
+		RPSHistory:   make([]float64, 0, MaxRPSHistory),
+		RecentErrors: make([]string, 0, MaxErrors),
+		TargetRPS:    config.Test.Rate,
+		StartTime:    agg.Start(),
+		aggregator:   agg,
+		series:       agg.Series(),
 	}
 }
 
-// UpdateMetrics обновляет метрики на основе новых результатов
-func (m *Metrics) UpdateMetrics(results []loadtest.Result) {
-	if len(results) == 0 {
-		return
-	}
-
-	// Валидация входных данных
-	if m.config == nil {
-		return
-	}
-
-	// Ограничиваем количество результатов в памяти
-	if len(results) > MaxResults {
-		results = results[len(results)-MaxResults:]
-	}
-
-	// НЕ сбрасываем метрики, а обновляем их
-	m.TotalRequests += len(results)
-
-	var latencies []time.Duration
-	var totalLatency time.Duration
-	var totalBytes int64
-
-	// Обрабатываем результаты
-	for _, result := range results {
-		// Добавляем timestamp для расчета RPS
-		m.requestTimestamps = append(m.requestTimestamps, result.Timestamp)
-
-		if result.Error != nil {
-			m.FailedRequests++
-			// Добавляем ошибку в лог (максимум MaxErrors)
-			if len(m.RecentErrors) >= MaxErrors {
-				m.RecentErrors = m.RecentErrors[1:]
-			}
-			m.RecentErrors = append(m.RecentErrors, result.Error.Error())
-		} else {
-			m.SuccessfulRequests++
-			latencies = append(latencies, result.Latency)
-			totalLatency += result.Latency
-		}
-
-		// Статус коды
-		if result.Status > 0 {
-			m.StatusCodes[result.Status]++
-		}
-
-		// Байты
-		totalBytes += result.Bytes
-	}
+// Refresh перечитывает снимок агрегатора и пересчитывает производные метрики
+func (m *Metrics) Refresh() {
+	now := time.Now()
+	snap := m.aggregator.Snapshot()
 
-	// Успешность с валидацией
+	// Основные метрики
+	m.TotalRequests = int(snap.Requests)
+	m.FailedRequests = int(snap.Failed)
+	m.SuccessfulRequests = int(snap.Requests - snap.Failed)
+	m.TotalBytes = snap.Bytes
 	if m.TotalRequests > 0 {
 		m.SuccessRate = float64(m.SuccessfulRequests) / float64(m.TotalRequests) * 100
 		m.ErrorRate = float64(m.FailedRequests) / float64(m.TotalRequests) * 100
-
-		// Валидация: сумма успешных и неудачных не должна превышать общее количество
-		if m.SuccessfulRequests+m.FailedRequests > m.TotalRequests {
-			// Корректируем если есть несоответствие
-			m.TotalRequests = m.SuccessfulRequests + m.FailedRequests
-		}
 	}
 
-	// Время отклика - обновляем только если есть новые успешные запросы
-	if len(latencies) > 0 {
-		sort.Slice(latencies, func(i, j int) bool {
-			return latencies[i] < latencies[j]
-		})
-
-		// Обновляем метрики латентности
-		m.AvgLatency = totalLatency / time.Duration(len(latencies))
-		if m.MinLatency == 0 || latencies[0] < m.MinLatency {
-			m.MinLatency = latencies[0]
-		}
-		if latencies[len(latencies)-1] > m.MaxLatency {
-			m.MaxLatency = latencies[len(latencies)-1]
-		}
-
-		// Percentiles
-		m.P50Latency = m.calculatePercentile(latencies, 50)
-		m.P90Latency = m.calculatePercentile(latencies, 90)
-		m.P95Latency = m.calculatePercentile(latencies, 95)
-		m.P99Latency = m.calculatePercentile(latencies, 99)
+	// Время отклика за весь прогон
+	m.AvgLatency = snap.Latency.Mean
+	m.MinLatency = snap.Latency.Min
+	m.MaxLatency = snap.Latency.Max
+	m.P50Latency = snap.Latency.P50
+	m.P90Latency = snap.Latency.P90
+	m.P95Latency = snap.Latency.P95
+	m.P99Latency = snap.Latency.P99
+
+	m.P50ResponseTime = snap.ResponseTime.P50
+	m.P90ResponseTime = snap.ResponseTime.P90
+	m.P95ResponseTime = snap.ResponseTime.P95
+	m.P99ResponseTime = snap.ResponseTime.P99
+
+	// Статус коды и ошибки
+	clear(m.StatusCodes)
+	for code, count := range snap.StatusCodes {
+		m.StatusCodes[code] = int(count)
 	}
+	clear(m.GRPCCodes)
+	for code, count := range snap.GRPCCodes {
+		m.GRPCCodes[code] = int(count)
+	}
+	m.RecentErrors = snap.RecentErrors
+
+	// Итерации и стримы
+	m.Iterations = int(snap.Iterations)
+	m.AvgIterationDuration = snap.IterationDuration.Mean
+	m.P95IterationDuration = snap.IterationDuration.P95
+	m.Streams = int(snap.Streams)
+	m.StreamErrors = int(snap.StreamErrors)
+	m.StreamMessages = int(snap.StreamMessages)
+	m.AvgStreamSetup = snap.StreamSetup.Mean
+	m.P50MessageLatency = snap.MessageLatency.P50
+	m.P99MessageLatency = snap.MessageLatency.P99
+	m.HTTPPhases = snap.HTTP
 
 	// Время
-	m.ElapsedTime = time.Since(m.StartTime)
+	m.ElapsedTime = now.Sub(m.StartTime)
 	if m.config != nil {
 		m.RemainingTime = m.config.Test.Duration - m.ElapsedTime
 		if m.RemainingTime < 0 {
@@ -167,87 +175,39 @@ func (m *Metrics) UpdateMetrics(results []loadtest.Result) {
 		}
 	}
 
-	// RPS - используем скользящее окно
-	m.CurrentRPS = m.calculateCurrentRPS()
-	m.RequestsPerSecond = m.CurrentRPS
+	// Стадия и целевой RPS в текущий момент
+	m.Stage = m.profile.At(m.ElapsedTime)
+	m.TargetRPS = int(math.Round(m.Stage.Rate))
+
+	// RPS и перцентили последнего интервала - из временного ряда
+	m.updateSeries(now)
 
 	// Throughput
-	m.TotalBytes += totalBytes
 	if m.ElapsedTime.Seconds() > 0 {
+		m.IterationsPerSecond = float64(m.Iterations) / m.ElapsedTime.Seconds()
 		m.BytesPerSecond = int64(float64(m.TotalBytes) / m.ElapsedTime.Seconds())
 		m.ThroughputMBps = float64(m.BytesPerSecond) / (1024 * 1024)
 	}
-
-	// Обновляем историю RPS
-	m.updateRPSHistory()
-}
-
-// calculatePercentile вычисляет перцентиль для массива latencies
-func (m *Metrics) calculatePercentile(latencies []time.Duration, percentile int) time.Duration {
-	if len(latencies) == 0 {
-		return 0
-	}
-
-	index := int(float64(len(latencies)) * float64(percentile) / 100.0)
-	if index >= len(latencies) {
-		index = len(latencies) - 1
-	}
-
-	return latencies[index]
 }
 
-// calculateCurrentRPS вычисляет текущий RPS используя скользящее окно
-func (m *Metrics) calculateCurrentRPS() float64 {
-	if len(m.requestTimestamps) == 0 {
-		return 0
-	}
-
-	now := time.Now()
-	windowStart := now.Add(-m.windowSize)
-
-	// Подсчитываем запросы в окне
-	count := 0
-	for _, timestamp := range m.requestTimestamps {
-		if timestamp.After(windowStart) {
-			count++
-		}
-	}
-
-	// Очищаем старые timestamps
-	m.cleanOldTimestamps(now)
-
-	// Возвращаем RPS для окна
-	return float64(count) / m.windowSize.Seconds()
-}
-
-// cleanOldTimestamps удаляет timestamps старше окна
-func (m *Metrics) cleanOldTimestamps(now time.Time) {
-	windowStart := now.Add(-m.windowSize)
-
-	// Находим первый индекс, который нужно сохранить
-	keepFrom := 0
-	for i, timestamp := range m.requestTimestamps {
-		if timestamp.After(windowStart) {
-			keepFrom = i
-			break
-		}
+// updateSeries берет RPS, его историю и перцентили последнего завершенного
+// интервала из временного ряда
+func (m *Metrics) updateSeries(now time.Time) {
+	points := m.series.Completed(now, MaxRPSHistory)
+	if len(points) == 0 {
+		return
 	}
 
-	// Удаляем старые timestamps
-	if keepFrom > 0 {
-		m.requestTimestamps = m.requestTimestamps[keepFrom:]
+	m.RPSHistory = m.RPSHistory[:0]
+	for _, p := range points {
+		m.RPSHistory = append(m.RPSHistory, m.series.Rate(p))
 	}
-}
 
-// updateRPSHistory обновляет историю RPS
-func (m *Metrics) updateRPSHistory() {
-	// Добавляем текущий RPS в историю
-	m.RPSHistory = append(m.RPSHistory, m.CurrentRPS)
-
-	// Ограничиваем размер истории
-	if len(m.RPSHistory) > MaxRPSHistory {
-		m.RPSHistory = m.RPSHistory[1:]
-	}
+	last := points[len(points)-1]
+	m.CurrentRPS = m.series.Rate(last)
+	m.RequestsPerSecond = m.CurrentRPS
+	m.IntervalP50Latency = last.P50
+	m.IntervalP99Latency = last.P99
 }
 
 // GetProgress возвращает прогресс выполнения теста (0.0 - 1.0)
@@ -264,6 +224,20 @@ func (m *Metrics) GetProgress() float64 {
 	return progress
 }
 
+// GetStageBoundaries возвращает положение границ стадий (0.0 - 1.0)
+func (m *Metrics) GetStageBoundaries() []float64 {
+	if m.profile.Duration() == 0 {
+		return nil
+	}
+
+	var result []float64
+	for _, bound := range m.profile.Boundaries() {
+		result = append(result, float64(bound)/float64(m.profile.Duration()))
+	}
+
+	return result
+}
+
 // IsTestFinished возвращает true, если тест завершен
 func (m *Metrics) IsTestFinished() bool {
 	return m.RemainingTime <= 0
@@ -294,6 +268,30 @@ func (m *Metrics) GetStatusCodesSorted() []StatusCodeInfo {
 	return result
 }
 
+// GetGRPCCodesSorted возвращает gRPC статус коды, отсортированные по количеству
+func (m *Metrics) GetGRPCCodesSorted() []GRPCCodeInfo {
+	var result []GRPCCodeInfo
+
+	for code, count := range m.GRPCCodes {
+		result = append(result, GRPCCodeInfo{Code: code, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count == result[j].Count {
+			return result[i].Code < result[j].Code
+		}
+		return result[i].Count > result[j].Count
+	})
+
+	return result
+}
+
+// GRPCCodeInfo содержит информацию о gRPC статус коде
+type GRPCCodeInfo struct {
+	Code  string
+	Count int
+}
+
 // StatusCodeInfo содержит информацию о статус коде
 type StatusCodeInfo struct {
 	Status     int
@@ -7,6 +7,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/paniccaaa/stresstea/internal/aggregator"
 	"github.com/paniccaaa/stresstea/internal/loadtest"
 	"github.com/paniccaaa/stresstea/internal/parser"
 )
@@ -18,11 +19,19 @@ const (
 	StatusRunning TestStatus = iota
 	StatusPaused
 	StatusStopped
+	StatusAborted
 )
 
+// abortMsg сообщает, что прогон остановлен порогом abort_on_fail
+type abortMsg struct {
+	reason string
+}
+
+// resultsDoneMsg сообщает, что все результаты прогона учтены
+type resultsDoneMsg struct{}
+
 // Константы для метрик
 const (
-	MaxResults    = 1000
 	MaxRPSHistory = 60
 	MaxErrors     = 10
 )
@@ -30,29 +39,40 @@ const (
 // CompactTUI представляет компактный TUI интерфейс
 type CompactTUI struct {
 	config      *parser.Config
+	tester      loadtest.LoadTester
 	metrics     *Metrics
-	results     []loadtest.Result
 	start       time.Time
 	width       int
 	height      int
-	resultsChan chan loadtest.Result
+	done        <-chan struct{}
+	refresh     time.Duration
+	abortChan   <-chan string
+	abortReason string
 	status      TestStatus
 	showHelp    bool
 }
 
-// NewCompactTUI создает новый компактный TUI
-func NewCompactTUI(cfg *parser.Config) *CompactTUI {
+// NewCompactTUI создает новый компактный TUI. Результаты учитывает агрегатор
+// движка, TUI только периодически читает его снимки.
+func NewCompactTUI(cfg *parser.Config, tester loadtest.LoadTester, agg *aggregator.Aggregator) *CompactTUI {
+	refresh := 100 * time.Millisecond
+	if cfg.App != nil && cfg.App.TUI != nil && cfg.App.TUI.RefreshRate > 0 {
+		refresh = time.Duration(cfg.App.TUI.RefreshRate) * time.Millisecond
+	}
+
 	return &CompactTUI{
 		config:  cfg,
-		metrics: NewMetrics(cfg),
+		tester:  tester,
+		metrics: NewMetrics(cfg, agg),
 		start:   time.Now(),
+		refresh: refresh,
 		status:  StatusRunning,
 	}
 }
 
-// Run запускает компактный TUI
-func (t *CompactTUI) Run(resultsChan chan loadtest.Result) error {
-	t.resultsChan = resultsChan
+// Run запускает компактный TUI. done закрывается, когда все результаты учтены.
+func (t *CompactTUI) Run(done <-chan struct{}) error {
+	t.done = done
 
 	p := tea.NewProgram(t, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
@@ -62,11 +82,16 @@ func (t *CompactTUI) Run(resultsChan chan loadtest.Result) error {
 	return nil
 }
 
+// WatchAbort подписывает TUI на причины досрочной остановки прогона
+func (t *CompactTUI) WatchAbort(reasons <-chan string) {
+	t.abortChan = reasons
+}
+
 // Init инициализирует модель
 func (t CompactTUI) Init() tea.Cmd {
 	return tea.Batch(
 		tea.EnterAltScreen,
-		t.waitForResults(),
+		t.waitForUpdate(),
 	)
 }
 
@@ -90,17 +115,24 @@ func (t CompactTUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		t.width = msg.Width
 		t.height = msg.Height
-	case loadtest.Result:
-		if t.status == StatusRunning {
-			t.results = append(t.results, msg)
-			if len(t.results) > 1000 {
-				t.results = t.results[len(t.results)-1000:]
-			}
-			t.metrics.UpdateMetrics(t.results)
+	case abortMsg:
+		t.status = StatusAborted
+		t.abortReason = msg.reason
+		return t, t.waitForUpdate()
+	case resultsDoneMsg:
+		t.done = nil
+		if t.status != StatusAborted {
+			t.status = StatusStopped
 		}
-		return t, t.waitForResults()
+		// Показываем итоговые значения
+		t.refreshMetrics()
+		return t, t.waitForUpdate()
 	case time.Time:
-		return t, t.waitForResults()
+		// На паузе замирает только экран, подсчет продолжается в движке
+		if t.status == StatusRunning {
+			t.refreshMetrics()
+		}
+		return t, t.waitForUpdate()
 	}
 
 	return t, nil
@@ -128,7 +160,7 @@ func (t CompactTUI) renderCompactView() string {
 		Bold(true).
 		Padding(0, 1).
 		Margin(0, 0, 1, 0).
-		Render("🚀 Stresstea - HTTP Load Testing")
+		Render(fmt.Sprintf("🚀 Stresstea - %s Load Testing", strings.ToUpper(t.config.Test.Protocol)))
 
 	// Основная информация в одну строку
 	mainInfo := t.renderMainInfo()
@@ -145,6 +177,15 @@ func (t CompactTUI) renderCompactView() string {
 	// Ошибки (если есть)
 	errors := t.renderErrors()
 
+	// Причина досрочной остановки
+	aborted := ""
+	if t.abortReason != "" {
+		aborted = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF0000")).
+			Bold(true).
+			Render("Aborted: " + t.abortReason)
+	}
+
 	// Помощь
 	help := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#626262")).
@@ -160,6 +201,7 @@ func (t CompactTUI) renderCompactView() string {
 		progress,
 		statusCodes,
 		errors,
+		aborted,
 		"",
 		help,
 	)
@@ -178,6 +220,8 @@ func (t CompactTUI) renderMainInfo() string {
 		statusColor, statusText = "#FFA500", "PAUSED"
 	case StatusStopped:
 		statusColor, statusText = "#FF0000", "STOPPED"
+	case StatusAborted:
+		statusColor, statusText = "#FF0000", "ABORTED"
 	}
 
 	status := lipgloss.NewStyle().
@@ -191,11 +235,35 @@ func (t CompactTUI) renderMainInfo() string {
 		Render(fmt.Sprintf("Target: %s", t.config.Test.Target))
 
 	// Параметры теста
-	params := fmt.Sprintf("Rate: %d RPS | Threads: %d | Duration: %v",
+	params := fmt.Sprintf("Rate: %d RPS | Max in-flight: %d | Duration: %v",
 		t.config.Test.Rate,
 		t.config.Test.Concurrent,
 		t.config.Test.Duration)
 
+	// Закрытая модель: фиксированное число пользователей
+	if t.config.Test.Executor == parser.ExecutorVUs {
+		params = fmt.Sprintf("VUs: %d | Duration: %v", t.config.Test.Concurrent, t.config.Test.Duration)
+		if think := t.config.Test.ThinkTime; think != nil {
+			params += fmt.Sprintf(" | Think: %v-%v", think.Min, think.Max)
+		}
+	}
+
+	// Текущая стадия профиля нагрузки
+	if stage := t.metrics.Stage; stage.Total > 0 {
+		params = fmt.Sprintf("Stage %d/%d → %d RPS | Target: %.0f RPS | Max in-flight: %d | Duration: %v",
+			stage.Index+1,
+			stage.Total,
+			stage.Target,
+			stage.Rate,
+			t.config.Test.Concurrent,
+			t.config.Test.Duration)
+	}
+
+	// Распределение запросов во времени
+	if arrival := t.config.Test.Arrival; arrival != nil && arrival.Type != "" && arrival.Type != parser.ArrivalConstant {
+		params += fmt.Sprintf(" | Arrival: %s (seed %d)", arrival.Type, arrival.Seed)
+	}
+
 	return lipgloss.JoinHorizontal(
 		lipgloss.Left,
 		status,
@@ -210,13 +278,33 @@ func (t CompactTUI) renderMainInfo() string {
 func (t CompactTUI) renderCompactMetrics() string {
 	// RPS и Success Rate в одну строку
 	rps := fmt.Sprintf("RPS: %.1f/%d", t.metrics.CurrentRPS, t.metrics.TargetRPS)
+	if t.config.Test.Executor == parser.ExecutorVUs {
+		rps = fmt.Sprintf("RPS: %.1f", t.metrics.CurrentRPS)
+	}
 	success := fmt.Sprintf("Success: %.1f%%", t.metrics.SuccessRate)
 
-	// Latency метрики
-	latency := fmt.Sprintf("Avg: %s | P90: %s | P99: %s",
+	// Итерации рядом с RPS
+	if t.metrics.Iterations > 0 {
+		rps += fmt.Sprintf(" | Iter/s: %.1f | Iter Avg: %s | Iter P95: %s",
+			t.metrics.IterationsPerSecond,
+			t.formatDuration(t.metrics.AvgIterationDuration),
+			t.formatDuration(t.metrics.P95IterationDuration))
+	}
+
+	// Latency метрики: время обслуживания и время ответа от запланированного старта
+	latency := fmt.Sprintf("Service  Avg: %s | P50: %s | P90: %s | P99: %s | Last %v P50: %s | P99: %s",
 		t.formatDuration(t.metrics.AvgLatency),
+		t.formatDuration(t.metrics.P50Latency),
 		t.formatDuration(t.metrics.P90Latency),
-		t.formatDuration(t.metrics.P99Latency))
+		t.formatDuration(t.metrics.P99Latency),
+		t.metrics.series.Interval(),
+		t.formatDuration(t.metrics.IntervalP50Latency),
+		t.formatDuration(t.metrics.IntervalP99Latency))
+	response := fmt.Sprintf("Response P50: %s | P90: %s | P95: %s | P99: %s",
+		t.formatDuration(t.metrics.P50ResponseTime),
+		t.formatDuration(t.metrics.P90ResponseTime),
+		t.formatDuration(t.metrics.P95ResponseTime),
+		t.formatDuration(t.metrics.P99ResponseTime))
 
 	// Requests и Errors
 	requests := fmt.Sprintf("Requests: %d | Errors: %d",
@@ -227,13 +315,54 @@ func (t CompactTUI) renderCompactMetrics() string {
 	throughput := fmt.Sprintf("Throughput: %.2f MB/s",
 		t.metrics.ThroughputMBps)
 
-	return lipgloss.JoinVertical(
-		lipgloss.Left,
+	// Планировщик: запросы в полете, опоздавшие и отброшенные
+	scheduler := fmt.Sprintf("In-flight: %d/%d | Late: %d | Dropped: %d",
+		t.metrics.Scheduler.InFlight,
+		t.metrics.Scheduler.Concurrency,
+		t.metrics.Scheduler.Late,
+		t.metrics.Scheduler.Dropped)
+	if t.config.Test.Executor == parser.ExecutorVUs {
+		scheduler = fmt.Sprintf("Active VUs: %d/%d", t.metrics.Scheduler.InFlight, t.metrics.Scheduler.Concurrency)
+	}
+	if t.metrics.Scheduler.Dropped > 0 {
+		scheduler = WarningStyle.Render(scheduler)
+	}
+
+	lines := []string{
 		lipgloss.JoinHorizontal(lipgloss.Left, rps, " | ", success),
 		latency,
+		response,
 		requests,
 		throughput,
-	)
+		scheduler,
+	}
+
+	// gRPC стримы (если есть)
+	if t.metrics.Streams > 0 || t.metrics.StreamMessages > 0 {
+		lines = append(lines, fmt.Sprintf("Streams: %d (errors: %d) | Setup: %s | Messages: %d | Msg P50: %s | Msg P99: %s",
+			t.metrics.Streams,
+			t.metrics.StreamErrors,
+			t.formatDuration(t.metrics.AvgStreamSetup),
+			t.metrics.StreamMessages,
+			t.formatDuration(t.metrics.P50MessageLatency),
+			t.formatDuration(t.metrics.P99MessageLatency)))
+	}
+
+	// Фазы HTTP запросов (p50/p99): сеть против медленного обработчика
+	if phases := t.metrics.HTTPPhases; phases.Requests > 0 {
+		phase := func(l aggregator.LatencyStats) string {
+			return t.formatDuration(l.P50) + "/" + t.formatDuration(l.P99)
+		}
+		lines = append(lines, fmt.Sprintf("Phases   DNS: %s | Connect: %s | TLS: %s | TTFB: %s | Transfer: %s | Reused: %.1f%%",
+			phase(phases.DNS),
+			phase(phases.Connect),
+			phase(phases.TLS),
+			phase(phases.TTFB),
+			phase(phases.Transfer),
+			phases.ReuseRate()))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
 }
 
 // renderProgress отображает прогресс
@@ -246,11 +375,23 @@ func (t CompactTUI) renderProgress() string {
 	barWidth := 40
 	filled := int(float64(barWidth) * progress)
 
-	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+	cells := []rune(strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled))
+
+	// Отмечаем границы стадий
+	for _, bound := range t.metrics.GetStageBoundaries() {
+		if i := int(float64(barWidth) * bound); i > 0 && i < barWidth {
+			cells[i] = '│'
+		}
+	}
+
+	label := fmt.Sprintf("[%s] %.1f%%", string(cells), progress*100)
+	if stage := t.metrics.Stage; stage.Total > 0 {
+		label += fmt.Sprintf(" | Stage %d/%d @ %.0f RPS", stage.Index+1, stage.Total, stage.Rate)
+	}
 
 	progressBar := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#7D56F4")).
-		Render(fmt.Sprintf("[%s] %.1f%%", bar, progress*100))
+		Render(label)
 
 	timeInfo := fmt.Sprintf("Elapsed: %v | Remaining: %v", elapsed, remaining)
 
@@ -263,7 +404,7 @@ func (t CompactTUI) renderProgress() string {
 
 // renderStatusCodes отображает статус коды
 func (t CompactTUI) renderStatusCodes() string {
-	if len(t.metrics.StatusCodes) == 0 {
+	if len(t.metrics.StatusCodes) == 0 && len(t.metrics.GRPCCodes) == 0 {
 		return ""
 	}
 
@@ -276,6 +417,14 @@ func (t CompactTUI) renderStatusCodes() string {
 		codes = append(codes, style.Render(fmt.Sprintf("%d: %d", codeInfo.Status, codeInfo.Count)))
 	}
 
+	for _, codeInfo := range t.metrics.GetGRPCCodesSorted() {
+		style := SuccessStyle.UnsetBold()
+		if codeInfo.Code != "OK" {
+			style = ErrorStyle.UnsetBold()
+		}
+		codes = append(codes, style.Render(fmt.Sprintf("%s: %d", codeInfo.Code, codeInfo.Count)))
+	}
+
 	if len(codes) > 0 {
 		return lipgloss.NewStyle().
 			Bold(true).
@@ -318,8 +467,17 @@ Controls:
 Metrics:
   RPS - Requests per second
   Success - Success rate percentage
-  Avg/P90/P99 - Response time percentiles
+  Service - Latency measured from the actual request start
+  Response - Latency measured from the scheduled start,
+             including time spent waiting for a free worker
+  Phases - HTTP request phases, p50/p99: DNS lookup, TCP connect,
+           TLS handshake, time to first byte, body transfer, and the
+           share of requests that reused a connection
   Throughput - Data transfer rate
+  Iter/s - Iterations per second (scenarios, vus mode)
+  In-flight - Running requests / concurrency cap
+  Late - Requests that waited for a free worker
+  Dropped - Requests skipped because the pool was saturated
 
 Press 'h' to close help`
 
@@ -330,20 +488,29 @@ Press 'h' to close help`
 		Render(helpText)
 }
 
-// waitForResults ожидает новые результаты
-func (t CompactTUI) waitForResults() tea.Cmd {
+// refreshMetrics перечитывает состояние прогона
+func (t CompactTUI) refreshMetrics() {
+	t.metrics.Refresh()
+	if t.tester != nil {
+		t.metrics.Scheduler = t.tester.Stats()
+	}
+}
+
+// waitForUpdate ждет следующего обновления экрана, остановки по порогу или
+// окончания прогона
+func (t CompactTUI) waitForUpdate() tea.Cmd {
 	return func() tea.Msg {
-		if t.resultsChan != nil {
-			select {
-			case result := <-t.resultsChan:
-				return result
-			default:
-				return tea.Tick(time.Millisecond*100, func(t time.Time) tea.Msg {
-					return t
-				})()
-			}
+		timer := time.NewTimer(t.refresh)
+		defer timer.Stop()
+
+		select {
+		case <-t.done:
+			return resultsDoneMsg{}
+		case reason := <-t.abortChan:
+			return abortMsg{reason: reason}
+		case now := <-timer.C:
+			return now
 		}
-		return nil
 	}
 }
 